@@ -33,8 +33,7 @@ func (a authorizeNoOp) Token() string {
 }
 
 type authorizeEmailPassword struct {
-	email       string
-	password    string
+	credentials CredentialsProvider
 	token       string
 	tokenValid  time.Time
 	client      *resty.Client
@@ -42,11 +41,10 @@ type authorizeEmailPassword struct {
 	tokenSingle singleflight.Group
 }
 
-func newAuthorizeEmailPassword(c *resty.Client, url string, email string, password string) authStore {
+func newAuthorizeEmailPassword(c *resty.Client, url string, credentials CredentialsProvider) authStore {
 	return &authorizeEmailPassword{
 		client:      c,
-		email:       email,
-		password:    password,
+		credentials: credentials,
 		url:         url,
 		tokenSingle: singleflight.Group{},
 	}
@@ -62,11 +60,16 @@ func (a *authorizeEmailPassword) authorize() error {
 			return nil, nil
 		}
 
+		credentials, err := a.credentials.Credentials()
+		if err != nil {
+			return nil, fmt.Errorf("[auth] can't load credentials %w", err)
+		}
+
 		resp, err := a.client.R().
 			SetHeader("Content-Type", "application/json").
 			SetBody(map[string]interface{}{
-				"identity": a.email,
-				"password": a.password,
+				"identity": credentials.Identity,
+				"password": credentials.Password,
 			}).
 			SetResult(&authResponse{}).
 			SetHeader("Authorization", "").
@@ -0,0 +1,17 @@
+package pocketbase
+
+// BaseRecord holds the fields every record has. Embed it in record structs:
+//
+//	type Post struct {
+//		pocketbase.BaseRecord
+//		Title string `json:"title"`
+//	}
+//
+// The id and collection fields are omitted when empty, so the struct can be sent to Create.
+type BaseRecord struct {
+	ID             string   `json:"id,omitempty"`
+	CollectionID   string   `json:"collectionId,omitempty"`
+	CollectionName string   `json:"collectionName,omitempty"`
+	Created        DateTime `json:"created"`
+	Updated        DateTime `json:"updated"`
+}
@@ -0,0 +1,292 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrBridgeClosed = errors.New("bridge closed")
+
+// DefaultHeartbeat is the interval of the bridge heartbeats when BridgeOptions.Heartbeat is not set.
+const DefaultHeartbeat = 30 * time.Second
+
+// bridgeCloseTimeout is how long Bridge.Close waits for a write to a client, which doesn't read.
+const bridgeCloseTimeout = time.Second
+
+type BridgeOptions struct {
+	// Authorize decides whether the request may receive the requested topics,
+	// an error rejects the request with 403. All topics are allowed by default.
+	Authorize func(r *http.Request, topics []string) error
+	// Heartbeat is the interval of the SSE comments, which keep idle connections open
+	// through proxies. DefaultHeartbeat by default, a negative value disables them.
+	Heartbeat time.Duration
+	// BufferSize is the number of events buffered per client, DefaultBufferSize by default.
+	// Clients which fall behind are disconnected.
+	BufferSize int
+}
+
+// Bridge is an http.Handler which re-broadcasts realtime events to its own SSE clients.
+// Clients choose the topics with the "topics" query parameter, e.g. "?topics=posts,comments/RECORD_ID",
+// and receive the events in the PocketBase format: the topic as the event name and
+// {"action": ..., "record": ...} as the data.
+//
+// Only SSE is served, there's no WebSocket handler: it would add a WebSocket dependency to the module,
+// and browsers receive the events with EventSource. Read the streams in your own handler for WebSocket clients.
+//
+// Example:
+//
+//	bridge := pocketbase.NewBridge(pocketbase.BridgeOptions{Authorize: checkSession})
+//	go pocketbase.Forward(bridge, "posts", stream)
+//	http.Handle("/events", bridge)
+//	...
+//	bridge.Close() // before http.Server.Shutdown, which doesn't end streaming responses
+type Bridge struct {
+	opts BridgeOptions
+
+	mu      sync.Mutex
+	clients map[*bridgeClient]struct{}
+	closed  bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+type bridgeClient struct {
+	topics map[string]struct{}
+	events chan bridgeEvent
+	slow   chan struct{}
+}
+
+type bridgeEvent struct {
+	topic string
+	data  []byte
+}
+
+func NewBridge(opts BridgeOptions) *Bridge {
+	if opts.Heartbeat == 0 {
+		opts.Heartbeat = DefaultHeartbeat
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	return &Bridge{
+		opts:    opts,
+		clients: map[*bridgeClient]struct{}{},
+		done:    make(chan struct{}),
+	}
+}
+
+// Forward publishes the events of the stream as records of the collection until the stream is terminated,
+// and returns its error.
+func Forward[T any](b *Bridge, collection string, stream *Stream[T]) error {
+	for e := range stream.Events() {
+		if e.Error != nil {
+			continue
+		}
+		record, err := json.Marshal(e.Record)
+		if err != nil {
+			return fmt.Errorf("[bridge] can't marshal record, err %w", err)
+		}
+		b.Publish(collection, e.Action, record)
+	}
+	return stream.Err()
+}
+
+// ForwardMany publishes the events of the stream until it's terminated, and returns its error.
+func (b *Bridge) ForwardMany(stream *MultiStream) error {
+	for e := range stream.Events() {
+		if e.Error != nil {
+			continue
+		}
+		b.Publish(e.Collection, e.Action, e.Raw)
+	}
+	return stream.Err()
+}
+
+// Publish sends the event to the clients subscribed to the collection, the record or all records ("collection/*").
+func (b *Bridge) Publish(collection string, action string, record json.RawMessage) {
+	data, err := json.Marshal(Event[json.RawMessage]{Action: action, Record: record})
+	if err != nil {
+		return
+	}
+	var r cacheRecord
+	_ = json.Unmarshal(record, &r)
+	topics := []string{collection, collection + "/*"}
+	if r.ID != "" {
+		topics = append(topics, collection+"/"+r.ID)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for c := range b.clients {
+		for _, topic := range topics {
+			if _, ok := c.topics[topic]; !ok {
+				continue
+			}
+			select {
+			case c.events <- bridgeEvent{topic: topic, data: data}:
+			default:
+				b.drop(c)
+			}
+		}
+	}
+}
+
+// Clients returns the number of connected clients.
+func (b *Bridge) Clients() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.clients)
+}
+
+// Close ends all client streams and rejects new ones, it returns once all handlers are done.
+func (b *Bridge) Close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var topics []string
+	for _, value := range r.URL.Query()["topics"] {
+		for _, topic := range strings.Split(value, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				topics = append(topics, topic)
+			}
+		}
+	}
+	if len(topics) == 0 {
+		http.Error(w, ErrNoTopics.Error(), http.StatusBadRequest)
+		return
+	}
+	if b.opts.Authorize != nil {
+		if err := b.opts.Authorize(r, topics); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	c := &bridgeClient{
+		topics: map[string]struct{}{},
+		events: make(chan bridgeEvent, b.opts.BufferSize),
+		slow:   make(chan struct{}),
+	}
+	for _, topic := range topics {
+		c.topics[topic] = struct{}{}
+	}
+	if err := b.add(c); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer b.wg.Done()
+	defer b.remove(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// a client which doesn't read blocks the writes once the connection buffers are full,
+	// the write deadline interrupts them when it's dropped or the bridge is closed
+	rc := http.NewResponseController(w)
+	stop := make(chan struct{})
+	deadlineSet := make(chan bool, 1)
+	defer func() {
+		close(stop)
+		// the deadline is only set while the handler runs, and cleared for a kept-alive connection
+		if <-deadlineSet {
+			_ = rc.SetWriteDeadline(time.Time{})
+		}
+	}()
+	go func() {
+		var deadline time.Time
+		select {
+		case <-stop:
+			deadlineSet <- false
+			return
+		case <-c.slow:
+			deadline = time.Now()
+		case <-b.done:
+			// the handlers of reading clients end their responses meanwhile
+			deadline = time.Now().Add(bridgeCloseTimeout)
+		}
+		select {
+		case <-stop:
+			deadlineSet <- false
+			return
+		default:
+		}
+		_ = rc.SetWriteDeadline(deadline)
+		deadlineSet <- true
+	}()
+
+	var heartbeat <-chan time.Time
+	if b.opts.Heartbeat > 0 {
+		ticker := time.NewTicker(b.opts.Heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case e := <-c.events:
+			if _, err := fmt.Fprintf(w, "event:%s\ndata:%s\n\n", e.topic, e.data); err != nil {
+				return
+			}
+		case <-heartbeat:
+			if _, err := fmt.Fprint(w, ":heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-c.slow:
+			return
+		case <-b.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (b *Bridge) add(c *bridgeClient) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBridgeClosed
+	}
+	b.clients[c] = struct{}{}
+	b.wg.Add(1)
+	return nil
+}
+
+func (b *Bridge) remove(c *bridgeClient) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.clients, c)
+}
+
+// drop disconnects a slow client, it must be called with b.mu held.
+func (b *Bridge) drop(c *bridgeClient) {
+	if _, ok := b.clients[c]; !ok {
+		return
+	}
+	delete(b.clients, c)
+	close(c.slow)
+}
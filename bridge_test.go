@@ -0,0 +1,180 @@
+package pocketbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connectBridge opens a bridge stream and waits until the client is registered.
+func connectBridge(t *testing.T, server *httptest.Server, bridge *Bridge, query string) *sseDecoder {
+	clients := bridge.Clients()
+	resp, err := http.Get(server.URL + "?" + query)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	t.Cleanup(func() { resp.Body.Close() })
+
+	require.Eventually(t, func() bool {
+		return bridge.Clients() > clients
+	}, time.Second, time.Millisecond)
+	return newSSEDecoder(resp.Body)
+}
+
+func TestBridge(t *testing.T) {
+	bridge := NewBridge(BridgeOptions{
+		Authorize: func(r *http.Request, topics []string) error {
+			for _, topic := range topics {
+				if strings.HasPrefix(topic, "secrets") {
+					return errors.New("forbidden topic")
+				}
+			}
+			return nil
+		},
+	})
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+
+	posts := connectBridge(t, server, bridge, "topics=posts")
+	record := connectBridge(t, server, bridge, "topics=comments/c2&topics=posts/p2")
+
+	bridge.Publish("posts", ActionCreate, json.RawMessage(`{"id":"p1"}`))
+	bridge.Publish("comments", ActionCreate, json.RawMessage(`{"id":"c1"}`))
+	bridge.Publish("comments", ActionUpdate, json.RawMessage(`{"id":"c2"}`))
+	bridge.Publish("posts", ActionDelete, json.RawMessage(`{"id":"p2"}`))
+
+	t.Run("collection topic", func(t *testing.T) {
+		ev, err := posts.Decode()
+		require.NoError(t, err)
+		assert.Equal(t, sseEvent{Event: "posts", Data: `{"action":"create","record":{"id":"p1"}}`}, ev)
+		ev, err = posts.Decode()
+		require.NoError(t, err)
+		assert.Equal(t, sseEvent{Event: "posts", Data: `{"action":"delete","record":{"id":"p2"}}`}, ev)
+	})
+
+	t.Run("record topics", func(t *testing.T) {
+		ev, err := record.Decode()
+		require.NoError(t, err)
+		assert.Equal(t, sseEvent{Event: "comments/c2", Data: `{"action":"update","record":{"id":"c2"}}`}, ev)
+		ev, err = record.Decode()
+		require.NoError(t, err)
+		assert.Equal(t, sseEvent{Event: "posts/p2", Data: `{"action":"delete","record":{"id":"p2"}}`}, ev)
+	})
+
+	t.Run("rejected requests", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "?topics=posts,secrets")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, err = http.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("graceful shutdown", func(t *testing.T) {
+		bridge.Close()
+		_, err := posts.Decode()
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Zero(t, bridge.Clients())
+
+		resp, err := http.Get(server.URL + "?topics=posts")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	})
+}
+
+func TestBridge_Heartbeat(t *testing.T) {
+	bridge := NewBridge(BridgeOptions{Heartbeat: 20 * time.Millisecond})
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+	defer bridge.Close()
+
+	resp, err := http.Get(server.URL + "?topics=posts")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	buf := make([]byte, len(":heartbeat\n\n"))
+	_, err = io.ReadFull(resp.Body, buf)
+	require.NoError(t, err)
+	assert.Equal(t, ":heartbeat\n\n", string(buf))
+}
+
+func TestBridge_SlowClient(t *testing.T) {
+	bridge := NewBridge(BridgeOptions{BufferSize: 1})
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+	defer bridge.Close()
+
+	connectBridge(t, server, bridge, "topics=posts") // never read
+	payload := json.RawMessage(`{"id":"p1","text":"` + strings.Repeat("x", 64<<10) + `"}`)
+	assert.Eventually(t, func() bool {
+		bridge.Publish("posts", ActionUpdate, payload)
+		return bridge.Clients() == 0
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestBridge_CloseStalledClient(t *testing.T) {
+	bridge := NewBridge(BridgeOptions{BufferSize: 1024})
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+
+	connectBridge(t, server, bridge, "topics=posts") // stops reading
+	payload := json.RawMessage(`{"id":"p1","text":"` + strings.Repeat("x", 64<<10) + `"}`)
+	for range 512 {
+		bridge.Publish("posts", ActionUpdate, payload)
+	}
+	require.Equal(t, 1, bridge.Clients())
+	// the grace period starts when the bridge is closed, not when the client connected
+	time.Sleep(bridgeCloseTimeout)
+
+	start := time.Now()
+	closed := make(chan struct{})
+	go func() {
+		bridge.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(bridgeCloseTimeout + 5*time.Second):
+		t.Fatal("Close is blocked by the stalled client")
+	}
+	assert.GreaterOrEqual(t, time.Since(start), bridgeCloseTimeout/2)
+	assert.Zero(t, bridge.Clients())
+}
+
+func TestForward(t *testing.T) {
+	bridge := NewBridge(BridgeOptions{})
+	server := httptest.NewServer(bridge)
+	defer server.Close()
+	defer bridge.Close()
+	client := connectBridge(t, server, bridge, "topics=posts")
+
+	var recording bytes.Buffer
+	enc := json.NewEncoder(&recording)
+	for _, data := range []string{`{"action":"create","record":{"id":"p1"}}`, `{"action":"update","record":{"id":"p1"}}`} {
+		require.NoError(t, enc.Encode(RecordedEvent{Event: "posts", Data: data}))
+	}
+	stream := Replay[map[string]any](&recording, ReplayOptions{})
+	require.NoError(t, Forward(bridge, "posts", stream))
+
+	for _, action := range []string{ActionCreate, ActionUpdate} {
+		ev, err := client.Decode()
+		require.NoError(t, err)
+		var e Event[map[string]any]
+		require.NoError(t, json.Unmarshal([]byte(ev.Data), &e))
+		assert.Equal(t, action, e.Action)
+		assert.Equal(t, "p1", e.Record["id"])
+	}
+}
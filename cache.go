@@ -0,0 +1,407 @@
+package pocketbase
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type CacheOptions struct {
+	// TTL is the maximum age of cached records and snapshots.
+	// Records are kept until they're changed or evicted by default.
+	TTL time.Duration
+	// MaxSize is the maximum number of cached records, the least recently used are evicted first.
+	// Snapshots are served from memory only while all records fit. Unlimited by default.
+	MaxSize int
+}
+
+// CacheStats are the counters of a CachedCollection.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// HitRatio returns the share of hits in all lookups, 0 without lookups.
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// CachedCollection serves One and Snapshot from memory. The cache is populated by the lookups
+// and kept consistent by the realtime events of the collection. It's invalidated when the
+// realtime connection drops, and the lookups go to the server until it's reconnected.
+// Cached records are shared, so they must not be modified.
+//
+// Example:
+//
+//	cached, err := pocketbase.NewCachedCollection(collection, pocketbase.CacheOptions{MaxSize: 10000})
+//	defer cached.Close()
+//	post, err := cached.One("RECORD_ID")
+type CachedCollection[T any] struct {
+	*Collection[T]
+	opts   CacheOptions
+	stream *Stream[json.RawMessage]
+	single singleflight.Group
+
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List
+	live     bool
+	closed   bool
+	complete bool
+	loadedAt time.Time
+	fetches  map[*cacheFetch]struct{}
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type cacheEntry[T any] struct {
+	id      string
+	record  T
+	fetched time.Time
+}
+
+// cacheFetch tracks the changes while a fetch is in flight, the fetched data is older than them.
+type cacheFetch struct {
+	touched     map[string]struct{}
+	invalidated bool
+}
+
+// cacheRecord is the part of a record the cache needs to apply events.
+type cacheRecord struct {
+	ID string `json:"id"`
+}
+
+// NewCachedCollection subscribes to the collection over the shared realtime connection
+// and returns once the subscription is ready.
+func NewCachedCollection[T any](collection *Collection[T], opts CacheOptions) (*CachedCollection[T], error) {
+	stream, err := SubscribeWith[json.RawMessage](collection.Realtime(), SubscribeOptions{
+		// the resync event marks the reconnect in order with the events
+		CatchUp:       true,
+		CatchUpResync: true,
+	}, collection.Name)
+	if err != nil {
+		return nil, err
+	}
+	<-stream.Ready()
+
+	c := &CachedCollection[T]{
+		Collection: collection,
+		opts:       opts,
+		stream:     stream,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		live:       true,
+		fetches:    map[*cacheFetch]struct{}{},
+	}
+	go c.watch(stream.Events(), stream.States())
+	return c, nil
+}
+
+// One returns the record from the cache, or fetches and caches it.
+func (c *CachedCollection[T]) One(id string) (T, error) {
+	c.mu.Lock()
+	if record, ok := c.lookup(id); ok {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return record, nil
+	}
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	v, err, _ := c.single.Do("one/"+id, func() (any, error) {
+		f := c.beginFetch()
+		record, err := c.Collection.One(id)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.endFetch(f)
+		if err != nil {
+			return record, err
+		}
+		if _, touched := f.touched[id]; c.live && !f.invalidated && !touched {
+			c.store(id, record, time.Now())
+		}
+		return record, nil
+	})
+	record, _ := v.(T)
+	return record, err
+}
+
+// Snapshot returns all records of the collection sorted by id, from the cache if it holds all of them.
+// Otherwise they're fetched with FullList and cached.
+func (c *CachedCollection[T]) Snapshot() ([]T, error) {
+	c.mu.Lock()
+	if c.live && c.complete && !c.expired(c.loadedAt) {
+		records := c.records()
+		c.mu.Unlock()
+		c.hits.Add(1)
+		return records, nil
+	}
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	v, err, _ := c.single.Do("snapshot", func() (any, error) {
+		return c.load()
+	})
+	records, _ := v.([]T)
+	return records, err
+}
+
+// Invalidate drops all cached records.
+func (c *CachedCollection[T]) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clear()
+}
+
+// Stats returns the cache counters.
+func (c *CachedCollection[T]) Stats() CacheStats {
+	c.mu.Lock()
+	size := c.lru.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+	}
+}
+
+// Close unsubscribes from the realtime events, lookups go to the server afterwards.
+func (c *CachedCollection[T]) Close() {
+	c.stream.Unsubscribe()
+}
+
+func (c *CachedCollection[T]) load() ([]T, error) {
+	f := c.beginFetch()
+	ids, records, err := c.fetchAll()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endFetch(f)
+	if err != nil {
+		return nil, err
+	}
+	if !c.live || f.invalidated {
+		return records, nil
+	}
+
+	// the list replaces the cache, except the records changed by events in the meantime
+	kept := map[string]*list.Element{}
+	for id := range f.touched {
+		if el, ok := c.entries[id]; ok {
+			kept[id] = el
+		}
+	}
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+	now := time.Now()
+	for id, el := range kept {
+		entry := el.Value.(*cacheEntry[T])
+		c.entries[id] = c.lru.PushFront(entry)
+	}
+	c.complete = true
+	c.loadedAt = now
+	for i, id := range ids {
+		if _, touched := f.touched[id]; !touched {
+			c.store(id, records[i], now)
+		}
+	}
+	return records, nil
+}
+
+func (c *CachedCollection[T]) fetchAll() ([]string, []T, error) {
+	response, err := CollectionSet[json.RawMessage](c.Client, c.Name).FullList(ParamsList{Sort: "id"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0, len(response.Items))
+	records := make([]T, 0, len(response.Items))
+	for _, raw := range response.Items {
+		var r cacheRecord
+		var record T
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return nil, nil, fmt.Errorf("[cache] can't unmarshal record, err %w", err)
+		}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, nil, fmt.Errorf("[cache] can't unmarshal record, err %w", err)
+		}
+		ids = append(ids, r.ID)
+		records = append(records, record)
+	}
+	return ids, records, nil
+}
+
+func (c *CachedCollection[T]) watch(events <-chan Event[json.RawMessage], states <-chan StateChange) {
+	for events != nil || states != nil {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			c.apply(e)
+		case sc, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			switch sc.State {
+			case StateDisconnected, StateClosed:
+				c.mu.Lock()
+				c.live = false
+				c.closed = c.closed || sc.State == StateClosed
+				c.clear()
+				c.mu.Unlock()
+			}
+		}
+	}
+}
+
+func (c *CachedCollection[T]) apply(e Event[json.RawMessage]) {
+	if e.Action == ActionResync {
+		// all events from now on were received after the reconnect
+		c.mu.Lock()
+		c.live = !c.closed
+		c.clear()
+		c.mu.Unlock()
+		return
+	}
+	if e.Error != nil {
+		c.Client.logger.Printf("[cache] can't apply %s event, err %v", c.Name, e.Error)
+		return
+	}
+
+	var r cacheRecord
+	var record T
+	err := json.Unmarshal(e.Record, &r)
+	if err == nil {
+		err = json.Unmarshal(e.Record, &record)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.live {
+		return
+	}
+	if err != nil {
+		c.Client.logger.Printf("[cache] can't apply %s event, err %v", c.Name, err)
+		c.clear()
+		return
+	}
+
+	for f := range c.fetches {
+		f.touched[r.ID] = struct{}{}
+	}
+	switch e.Action {
+	case ActionCreate, ActionUpdate:
+		c.store(r.ID, record, time.Now())
+	case ActionDelete:
+		c.remove(r.ID)
+	}
+}
+
+// lookup, store, remove, clear, records and endFetch must be called with c.mu held.
+
+func (c *CachedCollection[T]) lookup(id string) (T, bool) {
+	var zero T
+	if !c.live {
+		return zero, false
+	}
+	el, ok := c.entries[id]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*cacheEntry[T])
+	if c.expired(entry.fetched) {
+		c.remove(id)
+		c.complete = false
+		return zero, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.record, true
+}
+
+func (c *CachedCollection[T]) store(id string, record T, fetched time.Time) {
+	if el, ok := c.entries[id]; ok {
+		entry := el.Value.(*cacheEntry[T])
+		entry.record = record
+		entry.fetched = fetched
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[id] = c.lru.PushFront(&cacheEntry[T]{id: id, record: record, fetched: fetched})
+	for c.opts.MaxSize > 0 && c.lru.Len() > c.opts.MaxSize {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry[T]).id)
+		c.evictions.Add(1)
+		c.complete = false
+	}
+}
+
+func (c *CachedCollection[T]) remove(id string) {
+	if el, ok := c.entries[id]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, id)
+	}
+}
+
+func (c *CachedCollection[T]) clear() {
+	c.entries = map[string]*list.Element{}
+	c.lru.Init()
+	c.complete = false
+	for f := range c.fetches {
+		f.invalidated = true
+	}
+}
+
+func (c *CachedCollection[T]) records() []T {
+	entries := make([]*cacheEntry[T], 0, c.lru.Len())
+	for el := c.lru.Front(); el != nil; el = el.Next() {
+		entries = append(entries, el.Value.(*cacheEntry[T]))
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].id < entries[j].id
+	})
+
+	records := make([]T, len(entries))
+	for i, entry := range entries {
+		records[i] = entry.record
+	}
+	return records
+}
+
+func (c *CachedCollection[T]) expired(fetched time.Time) bool {
+	return c.opts.TTL > 0 && time.Since(fetched) > c.opts.TTL
+}
+
+func (c *CachedCollection[T]) beginFetch() *cacheFetch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := &cacheFetch{touched: map[string]struct{}{}}
+	c.fetches[f] = struct{}{}
+	return f
+}
+
+func (c *CachedCollection[T]) endFetch(f *cacheFetch) {
+	delete(c.fetches, f)
+}
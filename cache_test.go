@@ -0,0 +1,201 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRecords serves the records of the "posts" collection of a fakeRealtime server.
+type fakeRecords struct {
+	mu      sync.Mutex
+	records map[string]map[string]any
+	views   atomic.Int32
+	lists   atomic.Int32
+}
+
+func (f *fakeRecords) set(id string, record map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record["id"] = id
+	f.records[id] = record
+}
+
+func (f *fakeRecords) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id, isView := strings.CutPrefix(r.URL.Path, "/api/collections/posts/records/")
+	if isView {
+		f.views.Add(1)
+		record, ok := f.records[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(record)
+		return
+	}
+
+	// catch up queries are ignored
+	if sort := r.URL.Query().Get("sort"); sort == "updated,id" || sort == "-updated,-id" {
+		_ = json.NewEncoder(w).Encode(ResponseList[map[string]any]{Page: 1, TotalPages: 1})
+		return
+	}
+	f.lists.Add(1)
+	response := ResponseList[map[string]any]{Page: 1, PerPage: 500, TotalPages: 1, TotalItems: len(f.records)}
+	for _, record := range f.records {
+		response.Items = append(response.Items, record)
+	}
+	sort.Slice(response.Items, func(i, j int) bool {
+		return response.Items[i]["id"].(string) < response.Items[j]["id"].(string)
+	})
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func newCachedTest(t *testing.T, opts CacheOptions) (*CachedCollection[map[string]any], *fakeRealtime, *fakeRecords) {
+	server := newFakeRealtime(t)
+	records := &fakeRecords{records: map[string]map[string]any{}}
+	server.records = records.handle
+	records.set("a", map[string]any{"title": "A"})
+	records.set("b", map[string]any{"title": "B"})
+
+	client := NewClient(server.URL)
+	client.client.SetRetryCount(0)
+	cached, err := NewCachedCollection(CollectionSet[map[string]any](client, "posts"), opts)
+	require.NoError(t, err)
+	t.Cleanup(cached.Close)
+	return cached, server, records
+}
+
+func TestCachedCollection_One(t *testing.T) {
+	cached, server, records := newCachedTest(t, CacheOptions{})
+	conn := server.conn(t)
+
+	record, err := cached.One("a")
+	require.NoError(t, err)
+	assert.Equal(t, "A", record["title"])
+	record, err = cached.One("a")
+	require.NoError(t, err)
+	assert.Equal(t, "A", record["title"])
+	assert.Equal(t, int32(1), records.views.Load())
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Size: 1}, cached.Stats())
+	assert.Equal(t, 0.5, cached.Stats().HitRatio())
+
+	t.Run("update event", func(t *testing.T) {
+		records.set("a", map[string]any{"title": "A2"})
+		conn.send("posts", `{"action":"update","record":{"id":"a","title":"A2"}}`)
+		assert.Eventually(t, func() bool {
+			record, err := cached.One("a")
+			return err == nil && record["title"] == "A2"
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(1), records.views.Load())
+	})
+
+	t.Run("delete event", func(t *testing.T) {
+		records.mu.Lock()
+		delete(records.records, "a")
+		records.mu.Unlock()
+		conn.send("posts", `{"action":"delete","record":{"id":"a"}}`)
+		assert.Eventually(t, func() bool {
+			return cached.Stats().Size == 0
+		}, time.Second, 10*time.Millisecond)
+		_, err := cached.One("a")
+		assert.ErrorIs(t, err, ErrInvalidResponse)
+	})
+}
+
+func TestCachedCollection_Snapshot(t *testing.T) {
+	cached, server, records := newCachedTest(t, CacheOptions{})
+	conn := server.conn(t)
+
+	titles := func() []string {
+		snapshot, err := cached.Snapshot()
+		require.NoError(t, err)
+		var titles []string
+		for _, record := range snapshot {
+			titles = append(titles, record["title"].(string))
+		}
+		return titles
+	}
+
+	assert.Equal(t, []string{"A", "B"}, titles())
+	assert.Equal(t, []string{"A", "B"}, titles())
+	assert.Equal(t, int32(1), records.lists.Load())
+
+	conn.send("posts", `{"action":"create","record":{"id":"c","title":"C"}}`)
+	assert.Eventually(t, func() bool {
+		return cached.Stats().Size == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"A", "B", "C"}, titles())
+	assert.Equal(t, int32(1), records.lists.Load())
+
+	_, err := cached.One("b")
+	require.NoError(t, err)
+	assert.Zero(t, records.views.Load())
+}
+
+func TestCachedCollection_Limits(t *testing.T) {
+	t.Run("size", func(t *testing.T) {
+		cached, _, records := newCachedTest(t, CacheOptions{MaxSize: 1})
+
+		_, err := cached.Snapshot()
+		require.NoError(t, err)
+		assert.Equal(t, CacheStats{Misses: 1, Evictions: 1, Size: 1}, cached.Stats())
+
+		// all records don't fit, so snapshots aren't cached
+		_, err = cached.Snapshot()
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), records.lists.Load())
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		cached, _, records := newCachedTest(t, CacheOptions{TTL: 50 * time.Millisecond})
+
+		_, err := cached.One("a")
+		require.NoError(t, err)
+		_, err = cached.One("a")
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), records.views.Load())
+
+		time.Sleep(60 * time.Millisecond)
+		_, err = cached.One("a")
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), records.views.Load())
+	})
+}
+
+func TestCachedCollection_Reconnect(t *testing.T) {
+	cached, server, records := newCachedTest(t, CacheOptions{})
+	conn := server.conn(t)
+
+	_, err := cached.One("a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, cached.Stats().Size)
+
+	conn.close()
+	server.conn(t)
+	assert.Eventually(t, func() bool {
+		// invalidated on disconnect and cached again after the resync
+		_, err := cached.One("a")
+		return err == nil && records.views.Load() >= 2 && cached.Stats().Size == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cached.Close()
+	assert.Eventually(t, func() bool {
+		_, err := cached.One("a")
+		return err == nil && cached.Stats().Size == 0
+	}, time.Second, 10*time.Millisecond)
+	views := records.views.Load()
+	_, err = cached.One("a")
+	require.NoError(t, err)
+	assert.Equal(t, views+1, records.views.Load(), "closed cache must not serve from memory")
+}
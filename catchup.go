@@ -0,0 +1,158 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const catchUpPageSize = 500
+
+type catchUpRecord struct {
+	ID      string   `json:"id"`
+	Created DateTime `json:"created"`
+	Updated string   `json:"updated"`
+}
+
+// see remembers the newest record of the received event data in the "updated,id" order.
+func (s *Stream[T]) see(data []byte) {
+	var e Event[catchUpRecord]
+	if err := json.Unmarshal(data, &e); err != nil {
+		return
+	}
+	s.seeUpdated(e.Record.Updated, e.Record.ID)
+}
+
+func (s *Stream[T]) seeUpdated(updated string, id string) {
+	s.catchUpMu.Lock()
+	defer s.catchUpMu.Unlock()
+
+	parsed, err := parseDateTime(updated)
+	if err != nil || parsed.IsZero() {
+		return
+	}
+	if s.lastSeen.precedes(parsed, id) {
+		s.lastSeen = WatchMark{Updated: updated, ID: id}
+	}
+}
+
+func (s *Stream[T]) markGap() {
+	s.catchUpMu.Lock()
+	defer s.catchUpMu.Unlock()
+	s.gap = true
+}
+
+// catchUpGap delivers records updated while the stream was disconnected.
+// On the first connect it only remembers the newest record of each topic, in case no event
+// is received before a reconnect. The server's "updated" is used, so clock skew doesn't matter.
+func (s *Stream[T]) catchUpGap() {
+	s.catchUpMu.Lock()
+	gap, lastSeen := s.gap, s.lastSeen
+	s.gap = false
+	s.catchUpMu.Unlock()
+
+	if gap && s.catchUpResync {
+		s.push(Event[T]{Action: ActionResync})
+	}
+	for _, topic := range s.topics {
+		s.catchUpMu.Lock()
+		since, marked := s.connectMarks[topic]
+		s.catchUpMu.Unlock()
+
+		var err error
+		switch {
+		case !marked:
+			err = s.markTopic(topic)
+		case gap:
+			if lastSeen.Updated != "" {
+				since = lastSeen
+			}
+			err = s.catchUpTopic(topic, since)
+		}
+		if err != nil {
+			s.client.logger.Printf("[realtime] catch up failed, err %v", err)
+			s.push(Event[T]{Error: err})
+		}
+	}
+}
+
+// markTopic remembers the newest record of the topic, where catching up starts without received events.
+func (s *Stream[T]) markTopic(topic string) error {
+	collection, filter := catchUpFilter(topic, WatchMark{})
+	var response ResponseList[catchUpRecord]
+	_, err := s.client.List(collection, ParamsList{
+		Page:            1,
+		Size:            1,
+		Filters:         filter,
+		Sort:            "-updated,-id",
+		hackResponseRef: &response,
+	})
+	if err != nil {
+		return fmt.Errorf("[realtime] can't mark %s, err %w", topic, err)
+	}
+
+	var mark WatchMark
+	if len(response.Items) > 0 {
+		mark = WatchMark{Updated: response.Items[0].Updated, ID: response.Items[0].ID}
+	}
+	s.catchUpMu.Lock()
+	defer s.catchUpMu.Unlock()
+	s.connectMarks[topic] = mark
+	return nil
+}
+
+func (s *Stream[T]) catchUpTopic(topic string, since WatchMark) error {
+	collection, filter := catchUpFilter(topic, since)
+	for page := 1; ; page++ {
+		var response ResponseList[json.RawMessage]
+		_, err := s.client.List(collection, ParamsList{
+			Page:            page,
+			Size:            catchUpPageSize,
+			Filters:         filter,
+			Sort:            "updated,id",
+			hackResponseRef: &response,
+		})
+		if err != nil {
+			return fmt.Errorf("[realtime] can't catch up %s, err %w", topic, err)
+		}
+
+		for _, raw := range response.Items {
+			var r catchUpRecord
+			_ = json.Unmarshal(raw, &r)
+
+			e := Event[T]{Action: ActionUpdate}
+			if since.precedes(r.Created, r.ID) {
+				e.Action = ActionCreate
+			}
+			e.Error = json.Unmarshal(raw, &e.Record)
+			s.seeUpdated(r.Updated, r.ID)
+			s.push(e)
+		}
+
+		if page >= response.TotalPages {
+			return nil
+		}
+	}
+}
+
+// catchUpFilter returns the collection of the topic and the filter for its records after the mark,
+// which are ordered by "updated,id" like the watched records.
+func catchUpFilter(topic string, after WatchMark) (collection string, filter string) {
+	collection, id := splitTopic(topic)
+	if id != "" && id != "*" {
+		filter = "id = '" + escapeFilterValue(id) + "'"
+	}
+	return collection, watchFilter(after, filter)
+}
+
+// splitTopic splits realtime topics like "posts", "posts/*" or "posts/RECORD_ID?options={}"
+// into the collection and the record id.
+func splitTopic(topic string) (collection string, id string) {
+	topic, _, _ = strings.Cut(topic, "?")
+	collection, id, _ = strings.Cut(topic, "/")
+	return collection, id
+}
+
+func escapeFilterValue(value string) string {
+	return strings.ReplaceAll(value, "'", "\\'")
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/duke-git/lancet/v2/convertor"
@@ -18,8 +19,19 @@ type (
 		url        string
 		authorizer authStore
 		token      string
+
+		logger          Logger
+		realtimeOptions SubscribeOptions
+		realtime        *Realtime
+		auth            authWatchers
 	}
 	ClientOption func(*Client)
+
+	// Logger is used to report errors of background work, e.g. realtime reconnects.
+	// *log.Logger satisfies this interface.
+	Logger interface {
+		Printf(format string, v ...any)
+	}
 )
 
 func NewClient(url string, opts ...ClientOption) *Client {
@@ -33,10 +45,12 @@ func NewClient(url string, opts ...ClientOption) *Client {
 		client:     client,
 		url:        url,
 		authorizer: authorizeNoOp{},
+		logger:     log.Default(),
 	}
 	for _, opt := range opts {
 		opt(c)
 	}
+	c.realtime = newRealtime(c, c.realtimeOptions)
 
 	return c
 }
@@ -48,20 +62,34 @@ func WithDebug() ClientOption {
 }
 
 func WithAdminEmailPassword(email, password string) ClientOption {
+	return WithAdminCredentials(StaticCredentials(email, password))
+}
+
+// WithAdminCredentials authorizes as an admin with credentials loaded from the provider at auth time.
+func WithAdminCredentials(provider CredentialsProvider) ClientOption {
 	return func(c *Client) {
-		c.authorizer = newAuthorizeEmailPassword(c.client, c.url+"/api/admins/auth-with-password", email, password)
+		c.authorizer = newAuthorizeEmailPassword(c.client, c.url+"/api/admins/auth-with-password", provider)
 	}
 }
 
 func WithUserEmailPassword(email, password string) ClientOption {
-	return func(c *Client) {
-		c.authorizer = newAuthorizeEmailPassword(c.client, c.url+"/api/collections/users/auth-with-password", email, password)
-	}
+	return WithUserCredentials(StaticCredentials(email, password))
+}
+
+// WithUserCredentials authorizes as a user of the "users" collection with credentials loaded from the provider at auth time.
+func WithUserCredentials(provider CredentialsProvider) ClientOption {
+	return WithUserCredentialsAndCollection(provider, "users")
 }
 
 func WithUserEmailPasswordAndCollection(email, password, collection string) ClientOption {
+	return WithUserCredentialsAndCollection(StaticCredentials(email, password), collection)
+}
+
+// WithUserCredentialsAndCollection authorizes as a record of the given auth collection
+// with credentials loaded from the provider at auth time.
+func WithUserCredentialsAndCollection(provider CredentialsProvider, collection string) ClientOption {
 	return func(c *Client) {
-		c.authorizer = newAuthorizeEmailPassword(c.client, c.url+"/api/collections/"+collection+"/auth-with-password", email, password)
+		c.authorizer = newAuthorizeEmailPassword(c.client, c.url+"/api/collections/"+collection+"/auth-with-password", provider)
 	}
 }
 
@@ -77,63 +105,111 @@ func WithUserToken(token string) ClientOption {
 	}
 }
 
+// WithLogger replaces the default logger of the standard log package.
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithRealtimeOptions configures the shared realtime connection returned by Client.Realtime.
+func WithRealtimeOptions(opts SubscribeOptions) ClientOption {
+	return func(c *Client) {
+		c.realtimeOptions = opts
+	}
+}
+
 func (c *Client) Authorize() error {
-	return c.authorizer.authorize()
+	if err := c.authorizer.authorize(); err != nil {
+		return err
+	}
+	c.checkAuthChange()
+	return nil
 }
 
 func (c *Client) Update(collection string, id string, body any) error {
+	_, err := c.update(collection, id, body, ParamsList{})
+	return err
+}
+
+// update sends the body and returns the updated record, params.Fields and params.Expand apply to it.
+func (c *Client) update(collection string, id string, body any, params ParamsList) ([]byte, error) {
 	if err := c.Authorize(); err != nil {
-		return err
+		return nil, err
 	}
 
 	request := c.client.R().
 		SetHeader("Content-Type", "application/json").
 		SetPathParam("collection", collection).
+		SetPathParam("id", id).
 		SetBody(body)
+	if params.Fields != "" {
+		request.SetQueryParam("fields", params.Fields)
+	}
+	if params.Expand != "" {
+		request.SetQueryParam("expand", params.Expand)
+	}
 
-	resp, err := request.Patch(c.url + "/api/collections/{collection}/records/" + id)
+	resp, err := request.Patch(c.url + "/api/collections/{collection}/records/{id}")
 	if err != nil {
-		return fmt.Errorf("[update] can't send update request to pocketbase, err %w", err)
+		return nil, fmt.Errorf("[update] can't send update request to pocketbase, err %w", err)
 	}
 	if resp.IsError() {
-		return fmt.Errorf("[update] pocketbase returned status: %d, msg: %s, err %w",
+		return nil, fmt.Errorf("[update] pocketbase returned status: %d, msg: %s, err %w",
 			resp.StatusCode(),
 			resp.String(),
-			ErrInvalidResponse,
+			newResponseError(resp),
 		)
 	}
 
-	return nil
+	return resp.Body(), nil
 }
 
 func (c *Client) Create(collection string, body any) (ResponseCreate, error) {
 	var response ResponseCreate
 
-	if err := c.Authorize(); err != nil {
+	data, err := c.create(collection, body, ParamsList{})
+	if err != nil {
 		return response, err
 	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return response, fmt.Errorf("[create] can't unmarshal response, err %w", err)
+	}
+	return response, nil
+}
+
+// create sends the body and returns the created record, params.Fields and params.Expand apply to it.
+func (c *Client) create(collection string, body any, params ParamsList) ([]byte, error) {
+	if err := c.Authorize(); err != nil {
+		return nil, err
+	}
 
 	request := c.client.R().
 		SetHeader("Content-Type", "application/json").
 		SetPathParam("collection", collection).
-		SetBody(body).
-		SetResult(&response)
+		SetBody(body)
+	if params.Fields != "" {
+		request.SetQueryParam("fields", params.Fields)
+	}
+	if params.Expand != "" {
+		request.SetQueryParam("expand", params.Expand)
+	}
 
 	resp, err := request.Post(c.url + "/api/collections/{collection}/records")
 	if err != nil {
-		return response, fmt.Errorf("[create] can't send update request to pocketbase, err %w", err)
+		return nil, fmt.Errorf("[create] can't send update request to pocketbase, err %w", err)
 	}
 
 	if resp.IsError() {
-		return response, fmt.Errorf("[create] pocketbase returned status: %d, msg: %s, body: %s, err %w",
+		return nil, fmt.Errorf("[create] pocketbase returned status: %d, msg: %s, body: %s, err %w",
 			resp.StatusCode(),
 			resp.String(),
 			fmt.Sprintf("%+v", body), // TODO remove that after debugging
-			ErrInvalidResponse,
+			newResponseError(resp),
 		)
 	}
 
-	return *resp.Result().(*ResponseCreate), nil
+	return resp.Body(), nil
 }
 
 func (c *Client) Delete(collection string, id string) error {
@@ -155,15 +231,15 @@ func (c *Client) Delete(collection string, id string) error {
 		return fmt.Errorf("[delete] pocketbase returned status: %d, msg: %s, err %w",
 			resp.StatusCode(),
 			resp.String(),
-			ErrInvalidResponse,
+			newResponseError(resp),
 		)
 	}
 
 	return nil
 }
 
-func (c *Client) List(collection string, params ParamsList) (ResponseList[map[string]any], error) {
-	var response ResponseList[map[string]any]
+func (c *Client) List(collection string, params ParamsList) (ResponseList[DynRecord], error) {
+	var response ResponseList[DynRecord]
 
 	if err := c.Authorize(); err != nil {
 		return response, err
@@ -201,7 +277,7 @@ func (c *Client) List(collection string, params ParamsList) (ResponseList[map[st
 		return response, fmt.Errorf("[list] pocketbase returned status: %d, msg: %s, err %w",
 			resp.StatusCode(),
 			resp.String(),
-			ErrInvalidResponse,
+			newResponseError(resp),
 		)
 	}
 
@@ -215,8 +291,8 @@ func (c *Client) List(collection string, params ParamsList) (ResponseList[map[st
 	return response, nil
 }
 
-func (c *Client) FullList(collection string, params ParamsList) (ResponseList[map[string]any], error) {
-	var response ResponseList[map[string]any]
+func (c *Client) FullList(collection string, params ParamsList) (ResponseList[DynRecord], error) {
+	var response ResponseList[DynRecord]
 	params.Page = 1
 	params.Size = 500
 
@@ -250,6 +326,12 @@ func (c *Client) AuthStore() authStore {
 	return c.authorizer
 }
 
+// Realtime returns the manager of the shared realtime connection,
+// which multiplexes many subscriptions over a single SSE connection.
+func (c *Client) Realtime() *Realtime {
+	return c.realtime
+}
+
 func (c *Client) Backup() Backup {
 	return Backup{
 		Client: c,
@@ -261,3 +343,9 @@ func (c *Client) Files() Files {
 		Client: c,
 	}
 }
+
+func (c *Client) Collections() Collections {
+	return Collections{
+		Client: c,
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case in Go names, e.g. "user_id" becomes UserID.
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// goName converts a collection, field or select value name to an exported Go name.
+func goName(name string) string {
+	var parts []string
+	var part []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(part) > 0 {
+				parts = append(parts, string(part))
+				part = nil
+			}
+			continue
+		}
+		// camelCase boundary
+		if unicode.IsUpper(r) && len(part) > 0 && i > 0 && unicode.IsLower(runes[i-1]) {
+			parts = append(parts, string(part))
+			part = nil
+		}
+		part = append(part, r)
+	}
+	if len(part) > 0 {
+		parts = append(parts, string(part))
+	}
+
+	var b strings.Builder
+	for _, p := range parts {
+		if upper := strings.ToUpper(p); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(p)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+
+	s := b.String()
+	if s == "" || unicode.IsDigit([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+type generator struct {
+	// names of the collections by id, for relations
+	names map[string]string
+	// schema sources of the declared Go names, to detect different names mapped to the same Go name
+	declared map[string]string
+	err      error
+
+	buf        bytes.Buffer
+	importJSON bool
+}
+
+// declare records the Go name of a package level declaration generated from the source.
+func (g *generator) declare(name string, source string) {
+	g.unique(g.declared, name, source)
+}
+
+// unique records the name in the scope, the first collision is returned by generate
+// because the generated code wouldn't compile.
+func (g *generator) unique(scope map[string]string, name string, source string) {
+	if prev, ok := scope[name]; ok && g.err == nil {
+		g.err = fmt.Errorf("[pbgen] %s and %s are both generated as %s, rename one of them", prev, source, name)
+	}
+	scope[name] = source
+}
+
+// generate returns the formatted Go source of the record structs of the collections.
+func generate(pkg string, source string, collections []collection) ([]byte, error) {
+	g := &generator{
+		names:    map[string]string{},
+		declared: map[string]string{},
+	}
+	sorted := append([]collection(nil), collections...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, c := range sorted {
+		g.names[c.ID] = c.Name
+	}
+
+	g.printf("const (\n")
+	for _, c := range sorted {
+		g.declare(goName(c.Name)+"CollectionName", "collection "+c.Name)
+		g.printf("%sCollectionName = %q\n", goName(c.Name), c.Name)
+	}
+	g.printf(")\n\n")
+	for _, c := range sorted {
+		g.collection(c)
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by pbgen from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	out.WriteString("import (\n")
+	if g.importJSON {
+		out.WriteString("\"encoding/json\"\n\n")
+	}
+	out.WriteString("\"github.com/zcharym/pocketbase-client\"\n)\n\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("[pbgen] can't format the generated code, err %w", err)
+	}
+	return src, nil
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) collection(c collection) {
+	name := goName(c.Name)
+
+	var relations []field
+	for _, f := range c.Schema {
+		if f.Type == "relation" && g.names[f.Options.CollectionID] != "" {
+			relations = append(relations, f)
+		}
+	}
+
+	g.declare(name, "collection "+c.Name)
+	g.printf("// %s is a record of the %s collection.\n", name, c.Name)
+	g.printf("type %s struct {\n", name)
+	fields := map[string]string{}
+	system := func(field string, decl string) {
+		g.unique(fields, field, "system field "+field+" of "+c.Name)
+		g.printf("%s\n", decl)
+	}
+	if c.Type == "view" {
+		// views have no created and updated fields, unless they're selected
+		system("ID", "ID string `json:\"id,omitempty\"`")
+		system("CollectionID", "CollectionID string `json:\"collectionId,omitempty\"`")
+		system("CollectionName", "CollectionName string `json:\"collectionName,omitempty\"`")
+	} else {
+		system("BaseRecord", "pocketbase.BaseRecord")
+	}
+	if c.Type == "auth" {
+		system("Username", "Username string `json:\"username,omitempty\"`")
+		system("Email", "Email string `json:\"email,omitempty\"`")
+		system("EmailVisibility", "EmailVisibility bool `json:\"emailVisibility,omitempty\"`")
+		system("Verified", "Verified bool `json:\"verified,omitempty\"`")
+	}
+	for _, f := range c.Schema {
+		g.unique(fields, goName(f.Name), "field "+f.Name+" of "+c.Name)
+		g.printf("%s %s `json:%q`\n", goName(f.Name), g.fieldType(c, f), f.Name)
+	}
+	if len(relations) > 0 {
+		g.unique(fields, "Expand", "the expanded relations of "+c.Name)
+		g.printf("Expand *%sExpand `json:\"expand,omitempty\"`\n", name)
+	}
+	g.printf("}\n\n")
+
+	if len(relations) > 0 {
+		g.declare(name+"Expand", "the expand type of "+c.Name)
+		g.printf("// %sExpand holds the expanded relations of a %s record, see pocketbase.ParamsList.Expand.\n", name, name)
+		g.printf("type %sExpand struct {\n", name)
+		for _, f := range relations {
+			target := goName(g.names[f.Options.CollectionID])
+			if f.multiple() {
+				g.printf("%s []%s `json:\"%s,omitempty\"`\n", goName(f.Name), target, f.Name)
+			} else {
+				g.printf("%s *%s `json:\"%s,omitempty\"`\n", goName(f.Name), target, f.Name)
+			}
+		}
+		g.printf("}\n\n")
+	}
+
+	for _, f := range c.Schema {
+		if f.Type == "select" {
+			g.enum(c, f)
+		}
+	}
+
+	g.declare(name+"Collection", "the constructor of "+c.Name)
+	g.printf("// %sCollection returns the %s collection of the client.\n", name, c.Name)
+	g.printf("func %sCollection(client *pocketbase.Client) *pocketbase.Collection[%s] {\n", name, name)
+	g.printf("return pocketbase.CollectionSet[%s](client, %sCollectionName)\n", name, name)
+	g.printf("}\n\n")
+}
+
+func (g *generator) fieldType(c collection, f field) string {
+	var typ string
+	switch f.Type {
+	case "number":
+		return "float64"
+	case "bool":
+		return "bool"
+	case "date":
+		return "pocketbase.DateTime"
+	case "json":
+		g.importJSON = true
+		return "json.RawMessage"
+	case "select":
+		typ = goName(c.Name) + goName(f.Name)
+	case "relation", "file":
+		typ = "string"
+	default:
+		// text, editor, email, url and unknown kinds
+		return "string"
+	}
+	if f.multiple() {
+		return "[]" + typ
+	}
+	return typ
+}
+
+// enum declares the type of a select field with a constant per value.
+func (g *generator) enum(c collection, f field) {
+	typ := goName(c.Name) + goName(f.Name)
+	g.declare(typ, "select field "+f.Name+" of "+c.Name)
+	g.printf("// %s is a value of the %s field of the %s collection.\n", typ, f.Name, c.Name)
+	g.printf("type %s string\n\n", typ)
+	if len(f.Options.Values) == 0 {
+		return
+	}
+
+	seen := map[string]bool{}
+	g.printf("const (\n")
+	for _, value := range f.Options.Values {
+		name := typ + goName(value)
+		if value == "" {
+			name = typ + "Empty"
+		}
+		for i := 2; seen[name]; i++ {
+			name = fmt.Sprintf("%s%s%d", typ, goName(value), i)
+		}
+		seen[name] = true
+		g.declare(name, fmt.Sprintf("value %q of %s.%s", value, c.Name, f.Name))
+		g.printf("%s %s = %q\n", name, typ, value)
+	}
+	g.printf(")\n\n")
+}
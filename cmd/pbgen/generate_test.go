@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zcharym/pocketbase-client"
+)
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"posts_public":    "PostsPublic",
+		"emailVisibility": "EmailVisibility",
+		"user_id":         "UserID",
+		"source_url":      "SourceURL",
+		"in-review":       "InReview",
+		"2fa":             "X2fa",
+		"":                "X",
+	}
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, want, goName(name))
+		})
+	}
+}
+
+// normalize collapses the alignment of gofmt, so the generated fields can be compared line by line.
+func normalize(src []byte) string {
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestGenerate(t *testing.T) {
+	collections, err := loadFile(filepath.Join("testdata", "collections.json"))
+	require.NoError(t, err)
+
+	src, err := generate("models", "collections.json", collections)
+	require.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "collections.go", src, 0)
+	require.NoError(t, err)
+
+	code := normalize(src)
+	for _, want := range []string{
+		"// Code generated by pbgen from collections.json. DO NOT EDIT.",
+		"package models",
+		`"encoding/json"`,
+		`BlogPostsCollectionName = "blog_posts"`,
+		"type BlogPosts struct {\npocketbase.BaseRecord\n",
+		"Title string `json:\"title\"`",
+		"Body string `json:\"body\"`",
+		"Views float64 `json:\"views\"`",
+		"Published bool `json:\"published\"`",
+		"ContactEmail string `json:\"contact_email\"`",
+		"SourceURL string `json:\"source_url\"`",
+		"PublishedAt pocketbase.DateTime `json:\"publishedAt\"`",
+		"Status BlogPostsStatus `json:\"status\"`",
+		"Tags []BlogPostsTags `json:\"tags\"`",
+		"Author string `json:\"author\"`",
+		"Editors []string `json:\"editors\"`",
+		"Attachments []string `json:\"attachments\"`",
+		"Meta json.RawMessage `json:\"meta\"`",
+		"UserID string `json:\"user_id\"`",
+		"Expand *BlogPostsExpand `json:\"expand,omitempty\"`",
+		"type BlogPostsExpand struct {\nAuthor *Users `json:\"author,omitempty\"`\nEditors []Users `json:\"editors,omitempty\"`\n}",
+		"type BlogPostsStatus string",
+		`BlogPostsStatusInReview BlogPostsStatus = "in-review"`,
+		`BlogPostsTagsGo BlogPostsTags = "go"`,
+		"func BlogPostsCollection(client *pocketbase.Client) *pocketbase.Collection[BlogPosts] {\nreturn pocketbase.CollectionSet[BlogPosts](client, BlogPostsCollectionName)\n}",
+		"Username string `json:\"username,omitempty\"`",
+		"Avatar string `json:\"avatar\"`",
+		"type PostStats struct {\nID string `json:\"id,omitempty\"`\nCollectionID string `json:\"collectionId,omitempty\"`\nCollectionName string `json:\"collectionName,omitempty\"`\nTotal float64 `json:\"total\"`\n}",
+	} {
+		assert.Contains(t, code, want)
+	}
+	assert.NotContains(t, code, "type UsersExpand")
+}
+
+func TestGenerate_Collisions(t *testing.T) {
+	one := 1
+	posts := func(fields ...field) collection {
+		return collection{ID: "p", Name: "posts", Type: "base", Schema: fields}
+	}
+	relation := field{Name: "author", Type: "relation", Options: fieldOptions{MaxSelect: &one, CollectionID: "p"}}
+	status := field{Name: "status", Type: "select", Options: fieldOptions{MaxSelect: &one, Values: []string{"draft"}}}
+
+	tests := []struct {
+		name        string
+		collections []collection
+		want        string
+	}{
+		{
+			name:        "Fields",
+			collections: []collection{posts(field{Name: "user_id"}, field{Name: "userId"})},
+			want:        "field user_id of posts and field userId of posts are both generated as UserID",
+		},
+		{
+			name:        "Expand",
+			collections: []collection{posts(field{Name: "expand"}, relation)},
+			want:        "field expand of posts and the expanded relations of posts are both generated as Expand",
+		},
+		{
+			name:        "System field",
+			collections: []collection{{ID: "u", Name: "users", Type: "auth", Schema: []field{{Name: "verified"}}}},
+			want:        "system field Verified of users and field verified of users are both generated as Verified",
+		},
+		{
+			name:        "Select type",
+			collections: []collection{posts(status), {ID: "s", Name: "posts_status", Type: "base"}},
+			want:        "select field status of posts and collection posts_status are both generated as PostsStatus",
+		},
+		{
+			name:        "Collections",
+			collections: []collection{{ID: "a", Name: "blog_posts"}, {ID: "b", Name: "blogPosts"}},
+			want:        "collection blogPosts and collection blog_posts are both generated as BlogPostsCollectionName",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := generate("models", "collections.json", tt.collections)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Run("migration snapshot", func(t *testing.T) {
+		collections, err := loadFile(filepath.Join("..", "..", "migrations", "1668292995_collections_snapshot.go"))
+		require.NoError(t, err)
+
+		var names []string
+		for _, c := range collections {
+			names = append(names, c.Name)
+		}
+		assert.Equal(t, []string{"users", "posts_public", "posts_admin", "posts_user"}, names)
+		assert.Equal(t, "field", collections[1].Schema[0].Name)
+	})
+
+	t.Run("collections API response", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "response.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"page":1,"perPage":500,"totalItems":1,"totalPages":1,"items":[{"id":"c1","name":"posts","type":"base","schema":[]}]}`), 0o644))
+		collections, err := loadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []collection{{ID: "c1", Name: "posts", Type: "base", Schema: []field{}}}, collections)
+	})
+
+	t.Run("migration without collections", func(t *testing.T) {
+		_, err := loadFile(filepath.Join("..", "..", "migrations", "config.go"))
+		assert.ErrorIs(t, err, errNoCollections)
+	})
+}
+
+func TestFetchCollections(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		_, _ = fmt.Fprintf(w, `{"page":%s,"perPage":500,"totalItems":2,"totalPages":2,"items":[{"id":"c%s","name":"posts%s","type":"base","schema":[]}]}`, page, page, page)
+	}))
+	defer server.Close()
+
+	collections, err := fetchCollections(pocketbase.NewClient(server.URL))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, pages)
+	require.Len(t, collections, 2)
+	assert.Equal(t, "posts2", collections[1].Name)
+}
@@ -0,0 +1,71 @@
+// Command pbgen generates Go record structs from PocketBase collection schemas.
+//
+// The collections are read from the admin Collections API, an exported JSON file
+// or the JSON literal of a Go migration, e.g. an auto generated collections snapshot:
+//
+//	pbgen -file migrations/1668292995_collections_snapshot.go -package models -out models/collections.go
+//	PB_EMAIL=admin@admin.com PB_PASSWORD=... pbgen -url http://127.0.0.1:8090 -out models/collections.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/zcharym/pocketbase-client"
+)
+
+func main() {
+	var (
+		url      = flag.String("url", "", "PocketBase URL, the admin credentials are read from PB_EMAIL and PB_PASSWORD")
+		file     = flag.String("file", "", "exported collections JSON or Go migration with the collections JSON")
+		pkg      = flag.String("package", "models", "package name of the generated file")
+		out      = flag.String("out", "", "output file, stdout by default")
+		emailVar = flag.String("email-env", "PB_EMAIL", "environment variable of the admin email")
+		passVar  = flag.String("password-env", "PB_PASSWORD", "environment variable of the admin password")
+	)
+	flag.Parse()
+
+	if err := run(*url, *file, *pkg, *out, *emailVar, *passVar); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(url, file, pkg, out, emailVar, passVar string) error {
+	var (
+		collections []collection
+		source      string
+		err         error
+	)
+	switch {
+	case file != "" && url != "":
+		return fmt.Errorf("[pbgen] -file and -url are exclusive")
+	case file != "":
+		collections, err = loadFile(file)
+		source = filepath.Base(file)
+	case url != "":
+		client := pocketbase.NewClient(url, pocketbase.WithAdminCredentials(pocketbase.EnvCredentials(emailVar, passVar)))
+		collections, err = fetchCollections(client)
+		source = url
+	default:
+		return fmt.Errorf("[pbgen] -file or -url is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(pkg, source, collections)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		return fmt.Errorf("[pbgen] can't write %s, err %w", out, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/zcharym/pocketbase-client"
+)
+
+var errNoCollections = errors.New("no collections found")
+
+type collection struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	Schema []field `json:"schema"`
+}
+
+type field struct {
+	Name     string       `json:"name"`
+	Type     string       `json:"type"`
+	Required bool         `json:"required"`
+	Options  fieldOptions `json:"options"`
+}
+
+type fieldOptions struct {
+	// MaxSelect of select, relation and file fields, null means unlimited for relations
+	MaxSelect    *int     `json:"maxSelect"`
+	Values       []string `json:"values"`
+	CollectionID string   `json:"collectionId"`
+}
+
+// multiple reports whether the select, relation or file field holds several values.
+func (f field) multiple() bool {
+	return f.Options.MaxSelect == nil || *f.Options.MaxSelect != 1
+}
+
+// parseCollections reads an exported collections array or a response of the collections API.
+func parseCollections(data []byte) ([]collection, error) {
+	data = bytes.TrimSpace(data)
+	var collections []collection
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &collections); err != nil {
+			return nil, fmt.Errorf("[pbgen] can't unmarshal collections, err %w", err)
+		}
+	} else {
+		var response pocketbase.ResponseList[collection]
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, fmt.Errorf("[pbgen] can't unmarshal collections, err %w", err)
+		}
+		collections = response.Items
+	}
+
+	for _, c := range collections {
+		if c.Name == "" {
+			return nil, fmt.Errorf("[pbgen] collection %q has no name, err %w", c.ID, errNoCollections)
+		}
+	}
+	if len(collections) == 0 {
+		return nil, errNoCollections
+	}
+	return collections, nil
+}
+
+// loadFile reads the collections from an exported JSON file or from the JSON literal
+// of a Go migration, e.g. an auto generated collections snapshot.
+func loadFile(path string) ([]collection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("[pbgen] can't read %s, err %w", path, err)
+	}
+	if filepath.Ext(path) != ".go" {
+		return parseCollections(data)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), path, data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("[pbgen] can't parse %s, err %w", path, err)
+	}
+	var collections []collection
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING || collections != nil {
+			return collections == nil
+		}
+		value, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		if c, err := parseCollections([]byte(value)); err == nil {
+			collections = c
+		}
+		return true
+	})
+	if collections == nil {
+		return nil, fmt.Errorf("[pbgen] no collections JSON in %s, err %w", path, errNoCollections)
+	}
+	return collections, nil
+}
+
+// fetchCollections lists the collections with the admin Collections API.
+func fetchCollections(client *pocketbase.Client) ([]collection, error) {
+	items, err := client.Collections().FullList()
+	if err != nil {
+		return nil, fmt.Errorf("[pbgen] can't list collections, err %w", err)
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("[pbgen] can't marshal collections, err %w", err)
+	}
+	return parseCollections(data)
+}
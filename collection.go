@@ -2,14 +2,23 @@ package pocketbase
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 )
 
+var ErrNotFound = errors.New("record not found")
+
+// Collection is a typed view of the records of a collection, created with CollectionSet.
+// It has unexported fields for the options set by WithDerivedParams and WithVersionField,
+// so struct literals must name their fields, e.g. Collection[T]{Client: client, Name: name, ...}.
 type Collection[T any] struct {
 	*Client
 	Name               string
 	BaseCollectionPath string
+
+	deriveParams bool
+	versionField string
 }
 
 func CollectionSet[T any](client *Client, collection string) *Collection[T] {
@@ -24,59 +33,143 @@ func (c *Collection[T]) Update(id string, body T) error {
 	return c.Client.Update(c.Name, id, body)
 }
 
-func (c *Collection[T]) Create(body T) (ResponseCreate, error) {
-	return c.Client.Create(c.Name, body)
+// UpdateWithParams updates the record and returns it as PocketBase stored it (only fields and expand supported).
+//
+// Example:
+//
+//	post, err := posts.UpdateWithParams(id, post, pocketbase.ParamsList{Expand: "author"})
+func (c *Collection[T]) UpdateWithParams(id string, body T, params ParamsList) (T, error) {
+	return c.updateRecord(id, body, params)
 }
 
-func (c *Collection[T]) Delete(id string) error {
-	return c.Client.Delete(c.Name, id)
+// Patch sends only the fields changed from original to modified (see Diff) and returns the updated record.
+// Nothing is sent when no field changed, modified is returned then.
+//
+// Example:
+//
+//	post, _ := posts.One(id)
+//	changed := post
+//	changed.Draft = false // sent even though it's the zero value
+//	post, err = posts.Patch(id, post, changed)
+func (c *Collection[T]) Patch(id string, original, modified T) (T, error) {
+	patch, err := Diff(original, modified)
+	if err != nil {
+		return modified, err
+	}
+	if len(patch) == 0 {
+		return modified, nil
+	}
+	return c.UpdateFields(id, patch)
 }
 
-func (c *Collection[T]) List(params ParamsList) (ResponseList[T], error) {
-	var response ResponseList[T]
-	params.hackResponseRef = &response
+// UpdateFields updates the given fields only and returns the updated record.
+// The fields are a Patch or another map, or a struct whose unset Optional fields are left out.
+//
+// Example:
+//
+//	post, err := posts.UpdateFields(id, pocketbase.Patch{"title": "new title", "tags": []string{}})
+func (c *Collection[T]) UpdateFields(id string, fields any) (T, error) {
+	patch, err := patchOf(fields)
+	if err != nil {
+		var response T
+		return response, err
+	}
+	return c.updateRecord(id, patch, ParamsList{})
+}
 
-	_, err := c.Client.List(c.Name, params)
-	return response, err
+func (c *Collection[T]) updateRecord(id string, body any, params ParamsList) (T, error) {
+	var response T
+
+	data, err := c.Client.update(c.Name, id, body, c.params(params))
+	if err != nil {
+		return response, err
+	}
+	if err := decodeRecord(data, &response); err != nil {
+		return response, fmt.Errorf("[update] can't unmarshal response, err %w", err)
+	}
+	return response, nil
 }
 
-func (c *Collection[T]) FullList(params ParamsList) (ResponseList[T], error) {
-	var response ResponseList[T]
-	params.hackResponseRef = &response
+func (c *Collection[T]) Create(body T) (ResponseCreate, error) {
+	return c.Client.Create(c.Name, body)
+}
 
-	_, err := c.Client.FullList(c.Name, params)
-	return response, err
+// CreateWithParams creates the record and returns it as PocketBase stored it, including the id
+// and the generated fields (only fields and expand supported).
+//
+// Example:
+//
+//	post, err := posts.CreateWithParams(Post{Title: "hello", Author: userID}, pocketbase.ParamsList{Expand: "author"})
+//	log.Print(post.ID, post.Created, post.Author.Name)
+func (c *Collection[T]) CreateWithParams(body T, params ParamsList) (T, error) {
+	return c.createRecord(body, params)
 }
 
-func (c *Collection[T]) One(id string) (T, error) {
+func (c *Collection[T]) createRecord(body any, params ParamsList) (T, error) {
 	var response T
 
-	if err := c.Authorize(); err != nil {
+	data, err := c.Client.create(c.Name, body, c.params(params))
+	if err != nil {
 		return response, err
 	}
+	if err := decodeRecord(data, &response); err != nil {
+		return response, fmt.Errorf("[create] can't unmarshal response, err %w", err)
+	}
+	return response, nil
+}
 
-	request := c.client.R().
-		SetHeader("Content-Type", "application/json").
-		SetPathParam("collection", c.Name).
-		SetPathParam("id", id)
+func (c *Collection[T]) Delete(id string) error {
+	return c.Client.Delete(c.Name, id)
+}
 
-	resp, err := request.Get(c.url + "/api/collections/{collection}/records/{id}")
+func (c *Collection[T]) List(params ParamsList) (ResponseList[T], error) {
+	var raw ResponseList[json.RawMessage]
+	params = c.params(params)
+	params.hackResponseRef = &raw
+
+	_, err := c.Client.List(c.Name, params)
+	response := ResponseList[T]{
+		Page:       raw.Page,
+		PerPage:    raw.PerPage,
+		TotalItems: raw.TotalItems,
+		TotalPages: raw.TotalPages,
+	}
 	if err != nil {
-		return response, fmt.Errorf("[one] can't send update request to pocketbase, err %w", err)
+		return response, err
 	}
-
-	if resp.IsError() {
-		return response, fmt.Errorf("[one] pocketbase returned status: %d, msg: %s, err %w",
-			resp.StatusCode(),
-			resp.String(),
-			ErrInvalidResponse,
-		)
+	response.Items = make([]T, len(raw.Items))
+	for i, item := range raw.Items {
+		if err := decodeRecord(item, &response.Items[i]); err != nil {
+			return response, fmt.Errorf("[list] can't unmarshal response, err %w", err)
+		}
 	}
+	return response, nil
+}
 
-	if err := json.Unmarshal(resp.Body(), &response); err != nil {
-		return response, fmt.Errorf("[one] can't unmarshal response, err %w", err)
+func (c *Collection[T]) FullList(params ParamsList) (ResponseList[T], error) {
+	var response ResponseList[T]
+	params.Page = 1
+	params.Size = 500
+
+	for {
+		r, err := c.List(params)
+		if err != nil {
+			return response, err
+		}
+		if params.Page == 1 {
+			response = r
+		} else {
+			response.Items = append(response.Items, r.Items...)
+		}
+		if params.Page >= r.TotalPages {
+			return response, nil
+		}
+		params.Page++
 	}
-	return response, nil
+}
+
+func (c *Collection[T]) One(id string) (T, error) {
+	return c.OneWithParams(id, ParamsList{})
 }
 
 // Get one record with params (only fields and expand supported)
@@ -90,9 +183,15 @@ func (c *Collection[T]) OneWithParams(id string, params ParamsList) (T, error) {
 	request := c.client.R().
 		SetHeader("Content-Type", "application/json").
 		SetPathParam("collection", c.Name).
-		SetPathParam("id", id).
-		SetQueryParam("fields", params.Fields).
-		SetQueryParam("expand", params.Expand)
+		SetPathParam("id", id)
+
+	params = c.params(params)
+	if params.Fields != "" {
+		request.SetQueryParam("fields", params.Fields)
+	}
+	if params.Expand != "" {
+		request.SetQueryParam("expand", params.Expand)
+	}
 
 	resp, err := request.Get(c.url + "/api/collections/{collection}/records/{id}")
 	if err != nil {
@@ -103,12 +202,28 @@ func (c *Collection[T]) OneWithParams(id string, params ParamsList) (T, error) {
 		return response, fmt.Errorf("[one] pocketbase returned status: %d, msg: %s, err %w",
 			resp.StatusCode(),
 			resp.String(),
-			ErrInvalidResponse,
+			newResponseError(resp),
 		)
 	}
 
-	if err := json.Unmarshal(resp.Body(), &response); err != nil {
+	if err := decodeRecord(resp.Body(), &response); err != nil {
 		return response, fmt.Errorf("[one] can't unmarshal response, err %w", err)
 	}
 	return response, nil
 }
+
+// First returns the first record matching params.Filters in params.Sort order, ErrNotFound if there's none.
+func (c *Collection[T]) First(params ParamsList) (T, error) {
+	var record T
+	params.Page = 1
+	params.Size = 1
+
+	response, err := c.List(params)
+	if err != nil {
+		return record, err
+	}
+	if len(response.Items) == 0 {
+		return record, fmt.Errorf("[first] no %s record matches %q, err %w", c.Name, params.Filters, ErrNotFound)
+	}
+	return response.Items[0], nil
+}
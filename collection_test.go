@@ -1,6 +1,10 @@
 package pocketbase
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -57,7 +61,11 @@ func TestCollection_List(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			collection := Collection[map[string]any]{tt.client, tt.collection, defaultClient.url + "/api/collections/" + tt.collection}
+			collection := Collection[map[string]any]{
+				Client:             tt.client,
+				Name:               tt.collection,
+				BaseCollectionPath: defaultClient.url + "/api/collections/" + tt.collection,
+			}
 			got, err := collection.List(tt.params)
 			assert.Equal(t, tt.wantErr, err != nil, err)
 			assert.Equal(t, tt.wantResult, got.TotalItems > 0)
@@ -68,7 +76,11 @@ func TestCollection_List(t *testing.T) {
 func TestCollection_Delete(t *testing.T) {
 	client := NewClient(defaultURL)
 	field := "value_" + time.Now().Format(time.StampMilli)
-	collection := Collection[map[string]any]{client, migrations.PostsPublic, client.url + "/api/collections/" + "collectionname"}
+	collection := Collection[map[string]any]{
+		Client:             client,
+		Name:               migrations.PostsPublic,
+		BaseCollectionPath: client.url + "/api/collections/" + "collectionname",
+	}
 
 	// delete non-existing item
 	err := collection.Delete("non_existing_id")
@@ -99,7 +111,11 @@ func TestCollection_Delete(t *testing.T) {
 func TestCollection_Update(t *testing.T) {
 	client := NewClient(defaultURL)
 	field := "value_" + time.Now().Format(time.StampMilli)
-	collection := Collection[map[string]any]{client, migrations.PostsPublic, client.url + "/api/collections/collectionname"}
+	collection := Collection[map[string]any]{
+		Client:             client,
+		Name:               migrations.PostsPublic,
+		BaseCollectionPath: client.url + "/api/collections/collectionname",
+	}
 
 	// update non-existing item
 	err := collection.Update("non_existing_id", map[string]any{
@@ -178,7 +194,11 @@ func TestCollection_Create(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			collection := Collection[any]{tt.client, tt.collection, defaultClient.url + "/api/collections/" + tt.collection}
+			collection := Collection[any]{
+				Client:             tt.client,
+				Name:               tt.collection,
+				BaseCollectionPath: defaultClient.url + "/api/collections/" + tt.collection,
+			}
 			r, err := collection.Create(tt.body)
 			assert.Equal(t, tt.wantErr, err != nil, err)
 			assert.Equal(t, tt.wantID, r.ID != "")
@@ -189,7 +209,11 @@ func TestCollection_Create(t *testing.T) {
 func TestCollection_One(t *testing.T) {
 	client := NewClient(defaultURL)
 	field := "value_" + time.Now().Format(time.StampMilli)
-	collection := Collection[map[string]any]{client, migrations.PostsPublic, client.url + "/api/collections/collectionname"}
+	collection := Collection[map[string]any]{
+		Client:             client,
+		Name:               migrations.PostsPublic,
+		BaseCollectionPath: client.url + "/api/collections/collectionname",
+	}
 
 	// update non-existing item
 	_, err := collection.One("non_existing_id")
@@ -218,3 +242,66 @@ func TestCollection_One(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, field+"_updated", item["field"])
 }
+
+func TestCollection_FullList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		_, _ = fmt.Fprintf(w, `{"page":%d,"perPage":500,"totalItems":3,"totalPages":3,"items":[{"id":"r%d"}]}`, page, page)
+	}))
+	defer server.Close()
+
+	collection := CollectionSet[map[string]any](NewClient(server.URL), "posts")
+	response, err := collection.FullList(ParamsList{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, response.TotalItems)
+	require.Len(t, response.Items, 3)
+	for i, item := range response.Items {
+		assert.Equal(t, fmt.Sprintf("r%d", i+1), item["id"])
+	}
+}
+
+func TestCollection_CreateWithParams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/collections/posts/records":
+			_, _ = w.Write([]byte(`{"id":"p1","created":"2024-05-01 10:00:00.000Z","title":"hello","field":"f",` +
+				`"expand":{"author":{"id":"u1","name":"jane"}}}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/collections/posts/records/p1":
+			assert.Equal(t, "author", r.URL.Query().Get("expand"))
+			assert.Equal(t, "id,title", r.URL.Query().Get("fields"))
+			_, _ = w.Write([]byte(`{"id":"p1","title":"updated","expand":{"author":{"id":"u1","name":"jane"}}}`))
+		case r.URL.Path == "/api/collections/posts/records/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+
+	type post struct {
+		BaseRecord
+		Title  string      `json:"title"`
+		Author *expandUser `json:"-" pb:"expand=author"`
+	}
+	posts := CollectionSet[post](NewClient(server.URL), "posts")
+
+	created, err := posts.CreateWithParams(post{Title: "hello"}, ParamsList{Expand: "author"})
+	require.NoError(t, err)
+	assert.Equal(t, "p1", created.ID)
+	assert.False(t, created.Created.IsZero())
+	require.NotNil(t, created.Author)
+	assert.Equal(t, "jane", created.Author.Name)
+
+	updated, err := posts.UpdateWithParams("p1", created, ParamsList{Expand: "author", Fields: "id,title"})
+	require.NoError(t, err)
+	assert.Equal(t, "updated", updated.Title)
+	require.NotNil(t, updated.Author)
+
+	_, err = posts.UpdateWithParams("missing", created, ParamsList{})
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+
+	response, err := posts.Create(post{Title: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "p1", response.ID)
+	assert.Equal(t, "f", response.Field)
+}
@@ -0,0 +1,50 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/duke-git/lancet/v2/convertor"
+)
+
+// collectionsPageSize is the maximum page size of the Collections API.
+const collectionsPageSize = 500
+
+type Collections struct {
+	*Client
+}
+
+// FullList returns the schemas of all collections as returned by the Collections API, which requires an admin.
+func (c Collections) FullList() ([]json.RawMessage, error) {
+	if err := c.Authorize(); err != nil {
+		return nil, err
+	}
+
+	var collections []json.RawMessage
+	for page := 1; ; page++ {
+		resp, err := c.client.R().
+			SetHeader("Content-Type", "application/json").
+			SetQueryParam("page", convertor.ToString(page)).
+			SetQueryParam("perPage", convertor.ToString(collectionsPageSize)).
+			Get(c.url + "/api/collections")
+		if err != nil {
+			return nil, fmt.Errorf("[collections] can't send list request to pocketbase, err %w", err)
+		}
+		if resp.IsError() {
+			return nil, fmt.Errorf("[collections] pocketbase returned status: %d, msg: %s, err %w",
+				resp.StatusCode(),
+				resp.String(),
+				newResponseError(resp),
+			)
+		}
+
+		var response ResponseList[json.RawMessage]
+		if err := json.Unmarshal(resp.Body(), &response); err != nil {
+			return nil, fmt.Errorf("[collections] can't unmarshal response, err %w", err)
+		}
+		collections = append(collections, response.Items...)
+		if page >= response.TotalPages {
+			return collections, nil
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package pocketbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// ErrConflict is returned when the record was changed or deleted since it was read.
+var ErrConflict = errors.New("record was changed by another update")
+
+// DefaultConflictRetries is the number of retries of RetryOnConflict.
+const DefaultConflictRetries = 5
+
+// WithVersionField returns a copy of the collection, whose UpdateIfUnchanged also sets the number field
+// to the checked version + 1. With the update rule "@request.data.version > version" PocketBase
+// rejects an update based on an outdated version itself, when the request is handled instead of
+// before it's sent, which leaves a much smaller gap for concurrent updates.
+//
+// Example:
+//
+//	posts := pocketbase.CollectionSet[Post](client, "posts").WithVersionField("version")
+func (c *Collection[T]) WithVersionField(field string) *Collection[T] {
+	versioned := *c
+	versioned.versionField = field
+	return &versioned
+}
+
+// UpdateIfUnchanged updates the record only if it wasn't updated since expectedUpdated, e.g. the Updated
+// field of the record read before, and returns the updated record. It returns ErrConflict otherwise.
+// The body is a Patch or another map, or a struct whose unset Optional fields are left out, like in UpdateFields.
+//
+// Without a version field this is a check followed by a separate update, not an atomic operation:
+// the record is checked with a filtered query, and an update of another client which lands between
+// the check and the update is overwritten and not reported. Only a collection with a version field
+// and its update rule, see WithVersionField, rejects such an update.
+//
+// Example:
+//
+//	post, err := posts.One(id)
+//	...
+//	post, err = posts.UpdateIfUnchanged(id, post.Updated, pocketbase.Patch{"title": "new title"})
+//	if errors.Is(err, pocketbase.ErrConflict) {
+//		// reload and try again, see RetryOnConflict
+//	}
+func (c *Collection[T]) UpdateIfUnchanged(id string, expectedUpdated DateTime, body any) (T, error) {
+	var response T
+
+	patch, err := patchOf(body)
+	if err != nil {
+		return response, err
+	}
+
+	fields := "id,updated"
+	if c.versionField != "" {
+		fields += "," + c.versionField
+	}
+	current, err := CollectionSet[DynRecord](c.Client, c.Name).First(ParamsList{
+		Filters: fmt.Sprintf("id = '%s' && updated = '%s'", escapeFilterValue(id), expectedUpdated),
+		Fields:  fields,
+	})
+	if errors.Is(err, ErrNotFound) {
+		return response, fmt.Errorf("[conflict] %s record %s was updated since %s, err %w", c.Name, id, expectedUpdated, ErrConflict)
+	}
+	if err != nil {
+		return response, err
+	}
+	if c.versionField == "" {
+		return c.updateRecord(id, patch, ParamsList{})
+	}
+
+	version := current.GetInt(c.versionField)
+	patch[c.versionField] = version + 1
+	data, err := c.Client.update(c.Name, id, patch, c.params(ParamsList{}))
+	if err != nil {
+		// rejected by the update rule
+		if _, checkErr := CollectionSet[DynRecord](c.Client, c.Name).First(ParamsList{
+			Filters: fmt.Sprintf("id = '%s' && %s = %d", escapeFilterValue(id), c.versionField, version),
+			Fields:  "id",
+		}); errors.Is(checkErr, ErrNotFound) {
+			return response, fmt.Errorf("[conflict] %s record %s version %d was updated, err %w", c.Name, id, version, ErrConflict)
+		}
+		return response, err
+	}
+
+	if err := decodeRecord(data, &response); err != nil {
+		return response, fmt.Errorf("[update] can't unmarshal response, err %w", err)
+	}
+	return response, nil
+}
+
+// RetryOnConflict loads the record, applies mutate to it and sends the changed fields with UpdateIfUnchanged.
+// On ErrConflict it starts over with the reloaded record, spaced by an exponential backoff and at most
+// DefaultConflictRetries times. The errors of mutate end it. T must hold the updated field, e.g. by embedding BaseRecord.
+//
+// Example:
+//
+//	post, err := posts.RetryOnConflict(ctx, id, func(post *Post) error {
+//		post.Tags = append(post.Tags, tagID)
+//		return nil
+//	})
+func (c *Collection[T]) RetryOnConflict(ctx context.Context, id string, mutate func(record *T) error) (T, error) {
+	var response T
+
+	strategy := backoff.NewExponentialBackOff()
+	strategy.InitialInterval = 50 * time.Millisecond
+
+	operation := func() error {
+		original, err := c.One(id)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		var base struct {
+			Updated DateTime `json:"updated"`
+		}
+		if err := remarshal(original, &base); err != nil {
+			return backoff.Permanent(fmt.Errorf("[conflict] can't read the updated field, err %w", err))
+		}
+		if base.Updated.IsZero() {
+			return backoff.Permanent(fmt.Errorf("[conflict] %s record %s has no updated field", c.Name, id))
+		}
+
+		modified, err := copyRecord(original)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if err := mutate(&modified); err != nil {
+			return backoff.Permanent(err)
+		}
+		patch, err := Diff(original, modified)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if len(patch) == 0 {
+			response = original
+			return nil
+		}
+
+		response, err = c.UpdateIfUnchanged(id, base.Updated, patch)
+		if err != nil && !errors.Is(err, ErrConflict) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+	err := backoff.Retry(operation, backoff.WithContext(backoff.WithMaxRetries(strategy, DefaultConflictRetries), ctx))
+	return response, err
+}
+
+// patchOf returns the body as a Patch, leaving out the unset Optional fields of a struct.
+func patchOf(body any) (Patch, error) {
+	if v := indirectValue(reflect.ValueOf(body)); v.IsValid() && v.Kind() == reflect.Struct {
+		return diffStruct(reflect.Value{}, v)
+	}
+	var patch Patch
+	if err := remarshal(body, &patch); err != nil {
+		return nil, fmt.Errorf("[update] can't marshal body, err %w", err)
+	}
+	if patch == nil {
+		patch = Patch{}
+	}
+	return patch, nil
+}
+
+// copyRecord returns a deep copy of the record, so mutate doesn't change the maps and slices of the original.
+func copyRecord[T any](record T) (T, error) {
+	var copied T
+	data, err := json.Marshal(record)
+	if err != nil {
+		return copied, fmt.Errorf("[conflict] can't copy record, err %w", err)
+	}
+	if err := decodeRecord(data, &copied); err != nil {
+		return copied, fmt.Errorf("[conflict] can't copy record, err %w", err)
+	}
+	return copied, nil
+}
@@ -0,0 +1,201 @@
+package pocketbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zcharym/pocketbase-client/migrations"
+)
+
+type versionedPost struct {
+	BaseRecord
+	Field   string `json:"field"`
+	Version int    `json:"version"`
+}
+
+func TestCollection_UpdateIfUnchanged(t *testing.T) {
+	collection := CollectionSet[versionedPost](NewClient(defaultURL), migrations.PostsPublic)
+	created, err := collection.CreateWithParams(versionedPost{Field: "conflict"}, ParamsList{})
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, collection.Delete(created.ID)) }()
+
+	updated, err := collection.UpdateIfUnchanged(created.ID, created.Updated, Patch{"field": "first"})
+	require.NoError(t, err)
+	// updated has millisecond precision, an update in the same millisecond keeps it
+	for updated.Updated.Equal(created.Updated.Time) {
+		updated, err = collection.UpdateIfUnchanged(created.ID, updated.Updated, Patch{"field": "first"})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "first", updated.Field)
+	assert.True(t, updated.Updated.After(created.Updated.Time))
+
+	_, err = collection.UpdateIfUnchanged(created.ID, created.Updated, Patch{"field": "stale"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	_, err = collection.UpdateIfUnchanged("missing", created.Updated, Patch{"field": "stale"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	record, err := collection.One(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "first", record.Field)
+}
+
+func TestCollection_WithVersionField(t *testing.T) {
+	collection := CollectionSet[versionedPost](NewClient(defaultURL), migrations.PostsVersioned).WithVersionField("version")
+	created, err := collection.CreateWithParams(versionedPost{Field: "versioned"}, ParamsList{})
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, collection.Delete(created.ID)) }()
+
+	updated, err := collection.UpdateIfUnchanged(created.ID, created.Updated, versionedPost{Field: "first"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated.Version)
+	assert.Equal(t, "first", updated.Field)
+
+	// the update rule rejects an outdated version
+	_, err = collection.UpdateFields(created.ID, Patch{"field": "stale", "version": 1})
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+
+	t.Run("concurrent updates", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := collection.RetryOnConflict(context.Background(), created.ID, func(post *versionedPost) error {
+					post.Field += fmt.Sprintf(",%d", i)
+					return nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		record, err := collection.One(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 4, record.Version)
+		for _, i := range []string{"0", "1", "2"} {
+			assert.Contains(t, strings.Split(record.Field, ","), i)
+		}
+	})
+}
+
+// conflictServer serves a posts collection, whose version is changed by another client after the check.
+func conflictServer(t *testing.T, conflicts int) (*httptest.Server, *[]string) {
+	var (
+		mu      sync.Mutex
+		version = 3
+		patches []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		record := fmt.Sprintf(`{"id":"p1","updated":"2024-05-01 10:00:0%d.000Z","field":"f","version":%d}`, version, version)
+		switch {
+		case r.Method == http.MethodPatch:
+			body, _ := io.ReadAll(r.Body)
+			patches = append(patches, string(body))
+			if len(patches) <= conflicts {
+				version++
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var patch map[string]any
+			_ = json.Unmarshal(body, &patch)
+			version = int(patch["version"].(float64))
+			_, _ = fmt.Fprintf(w, `{"id":"p1","field":%q,"version":%d}`, patch["field"], version)
+		case r.URL.Path == "/api/collections/posts/records/p1":
+			_, _ = w.Write([]byte(record))
+		case strings.Contains(r.URL.Query().Get("filter"), fmt.Sprintf("version = %d", version)),
+			strings.Contains(r.URL.Query().Get("filter"), fmt.Sprintf("updated = '2024-05-01 10:00:0%d.000Z'", version)):
+			_, _ = fmt.Fprintf(w, `{"page":1,"perPage":1,"totalItems":1,"totalPages":1,"items":[%s]}`, record)
+		default:
+			_, _ = w.Write([]byte(`{"page":1,"perPage":1,"totalItems":0,"totalPages":0,"items":[]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &patches
+}
+
+func TestCollection_RetryOnConflict(t *testing.T) {
+	t.Run("rejected by the update rule", func(t *testing.T) {
+		server, patches := conflictServer(t, 1)
+		collection := CollectionSet[versionedPost](NewClient(server.URL), "posts").WithVersionField("version")
+
+		updated := NewDateTime(time.Date(2024, 5, 1, 10, 0, 3, 0, time.UTC))
+		_, err := collection.UpdateIfUnchanged("p1", updated, Patch{"field": "new"})
+		assert.ErrorIs(t, err, ErrConflict)
+		require.Len(t, *patches, 1)
+		assert.JSONEq(t, `{"field":"new","version":4}`, (*patches)[0])
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		server, patches := conflictServer(t, 2)
+		collection := CollectionSet[versionedPost](NewClient(server.URL), "posts").WithVersionField("version")
+
+		calls := 0
+		post, err := collection.RetryOnConflict(context.Background(), "p1", func(post *versionedPost) error {
+			calls++
+			post.Field = fmt.Sprintf("%s_%d", post.Field, post.Version)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, "f_5", post.Field)
+		assert.Equal(t, 6, post.Version)
+		assert.Len(t, *patches, 3)
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		server, patches := conflictServer(t, 100)
+		collection := CollectionSet[versionedPost](NewClient(server.URL), "posts").WithVersionField("version")
+
+		_, err := collection.RetryOnConflict(context.Background(), "p1", func(post *versionedPost) error {
+			post.Field = "new"
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.Len(t, *patches, DefaultConflictRetries+1)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		server, patches := conflictServer(t, 0)
+		collection := CollectionSet[versionedPost](NewClient(server.URL), "posts")
+
+		post, err := collection.RetryOnConflict(context.Background(), "p1", func(post *versionedPost) error { return nil })
+		require.NoError(t, err)
+		assert.Equal(t, "f", post.Field)
+		assert.Empty(t, *patches)
+	})
+
+	t.Run("mutate error", func(t *testing.T) {
+		server, _ := conflictServer(t, 0)
+		collection := CollectionSet[versionedPost](NewClient(server.URL), "posts")
+
+		errMutate := errors.New("invalid post")
+		_, err := collection.RetryOnConflict(context.Background(), "p1", func(post *versionedPost) error { return errMutate })
+		assert.ErrorIs(t, err, errMutate)
+	})
+
+	t.Run("no updated field", func(t *testing.T) {
+		type post struct {
+			Field string `json:"field"`
+		}
+		server, _ := conflictServer(t, 0)
+		collection := CollectionSet[post](NewClient(server.URL), "posts")
+
+		_, err := collection.RetryOnConflict(context.Background(), "p1", func(p *post) error { return nil })
+		assert.ErrorContains(t, err, "has no updated field")
+	})
+}
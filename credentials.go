@@ -0,0 +1,91 @@
+package pocketbase
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrMissingCredentials = errors.New("missing credentials")
+
+type (
+	// Credentials holds the identity (email or username) and password
+	// used by the email/password authorizers.
+	Credentials struct {
+		Identity string
+		Password string
+	}
+
+	// CredentialsProvider is consulted every time the client has to (re)authenticate,
+	// so implementations are free to fetch rotated secrets from an external source.
+	CredentialsProvider interface {
+		Credentials() (Credentials, error)
+	}
+
+	// CredentialsProviderFunc allows plain functions (e.g. a vault client call) to be used as a CredentialsProvider.
+	CredentialsProviderFunc func() (Credentials, error)
+)
+
+func (f CredentialsProviderFunc) Credentials() (Credentials, error) {
+	return f()
+}
+
+// StaticCredentials returns a provider that always returns the given identity and password.
+func StaticCredentials(identity, password string) CredentialsProvider {
+	return CredentialsProviderFunc(func() (Credentials, error) {
+		return Credentials{Identity: identity, Password: password}, nil
+	})
+}
+
+// EnvCredentials returns a provider that reads the identity and password
+// from the given environment variables on each authorization.
+func EnvCredentials(identityVar, passwordVar string) CredentialsProvider {
+	return CredentialsProviderFunc(func() (Credentials, error) {
+		identity, ok := os.LookupEnv(identityVar)
+		if !ok || identity == "" {
+			return Credentials{}, fmt.Errorf("[credentials] env variable %s is not set, err %w", identityVar, ErrMissingCredentials)
+		}
+		password, ok := os.LookupEnv(passwordVar)
+		if !ok || password == "" {
+			return Credentials{}, fmt.Errorf("[credentials] env variable %s is not set, err %w", passwordVar, ErrMissingCredentials)
+		}
+		return Credentials{Identity: identity, Password: password}, nil
+	})
+}
+
+// FileCredentials returns a provider that reads the identity and password
+// from the given files (e.g. Docker or Kubernetes secret mounts).
+//
+// The files are re-read on each authorization, so rotated secrets are picked up
+// without restarting the client. A single trailing newline is removed, other whitespace
+// is part of the secret.
+func FileCredentials(identityPath, passwordPath string) CredentialsProvider {
+	return CredentialsProviderFunc(func() (Credentials, error) {
+		identity, err := readSecretFile(identityPath)
+		if err != nil {
+			return Credentials{}, err
+		}
+		password, err := readSecretFile(passwordPath)
+		if err != nil {
+			return Credentials{}, err
+		}
+		return Credentials{Identity: identity, Password: password}, nil
+	})
+}
+
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path) // #nosec G304 -- path is provided by the SDK user
+	if err != nil {
+		return "", fmt.Errorf("[credentials] can't read secret file %s, err %w", path, err)
+	}
+	// editors and `echo` end files with a newline, which isn't part of the secret
+	secret := string(b)
+	if s, ok := strings.CutSuffix(secret, "\n"); ok {
+		secret = strings.TrimSuffix(s, "\r")
+	}
+	if secret == "" {
+		return "", fmt.Errorf("[credentials] secret file %s is empty, err %w", path, ErrMissingCredentials)
+	}
+	return secret, nil
+}
@@ -0,0 +1,142 @@
+package pocketbase
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zcharym/pocketbase-client/migrations"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity string
+		password string
+		want     Credentials
+		wantErr  bool
+	}{
+		{
+			name:     "Both variables set",
+			identity: "admin@admin.com",
+			password: "secret",
+			want:     Credentials{Identity: "admin@admin.com", Password: "secret"},
+		},
+		{
+			name:     "Missing password",
+			identity: "admin@admin.com",
+			wantErr:  true,
+		},
+		{
+			name:     "Missing identity",
+			password: "secret",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PB_TEST_IDENTITY", tt.identity)
+			t.Setenv("PB_TEST_PASSWORD", tt.password)
+
+			got, err := EnvCredentials("PB_TEST_IDENTITY", "PB_TEST_PASSWORD").Credentials()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrMissingCredentials)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	identityPath := filepath.Join(dir, "identity")
+	passwordPath := filepath.Join(dir, "password")
+	provider := FileCredentials(identityPath, passwordPath)
+
+	// missing files
+	_, err := provider.Credentials()
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(identityPath, []byte("admin@admin.com\n"), 0o600))
+	require.NoError(t, os.WriteFile(passwordPath, []byte("first\n"), 0o600))
+
+	got, err := provider.Credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{Identity: "admin@admin.com", Password: "first"}, got)
+
+	// rotated secret is picked up on the next call
+	require.NoError(t, os.WriteFile(passwordPath, []byte("second"), 0o600))
+
+	got, err = provider.Credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{Identity: "admin@admin.com", Password: "second"}, got)
+
+	// only the trailing newline is removed
+	require.NoError(t, os.WriteFile(passwordPath, []byte(" pass\tword \r\n"), 0o600))
+
+	got, err = provider.Credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, " pass\tword ", got.Password)
+
+	// empty secret
+	require.NoError(t, os.WriteFile(passwordPath, []byte("\n"), 0o600))
+
+	_, err = provider.Credentials()
+	assert.ErrorIs(t, err, ErrMissingCredentials)
+}
+
+func TestAuthorizeCredentialsProvider(t *testing.T) {
+	dir := t.TempDir()
+	identityPath := filepath.Join(dir, "identity")
+	passwordPath := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(identityPath, []byte(migrations.AdminEmailPassword), 0o600))
+	require.NoError(t, os.WriteFile(passwordPath, []byte(migrations.AdminEmailPassword), 0o600))
+
+	t.Setenv("PB_TEST_USER_IDENTITY", migrations.UserEmailPassword)
+	t.Setenv("PB_TEST_USER_PASSWORD", migrations.UserEmailPassword)
+
+	tests := []struct {
+		name       string
+		option     ClientOption
+		collection string
+		wantErr    bool
+	}{
+		{
+			name:       "Admin credentials from files",
+			option:     WithAdminCredentials(FileCredentials(identityPath, passwordPath)),
+			collection: migrations.PostsAdmin,
+			wantErr:    false,
+		},
+		{
+			name:       "User credentials from env",
+			option:     WithUserCredentials(EnvCredentials("PB_TEST_USER_IDENTITY", "PB_TEST_USER_PASSWORD")),
+			collection: migrations.PostsUser,
+			wantErr:    false,
+		},
+		{
+			name: "User credentials from custom provider",
+			option: WithUserCredentialsAndCollection(CredentialsProviderFunc(func() (Credentials, error) {
+				return Credentials{Identity: migrations.UserEmailPassword, Password: migrations.UserEmailPassword}, nil
+			}), "users"),
+			collection: migrations.PostsUser,
+			wantErr:    false,
+		},
+		{
+			name:       "Missing credentials",
+			option:     WithAdminCredentials(EnvCredentials("PB_TEST_MISSING_IDENTITY", "PB_TEST_MISSING_PASSWORD")),
+			collection: migrations.PostsAdmin,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(defaultURL, tt.option)
+			r, err := c.List(tt.collection, ParamsList{})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, !tt.wantErr, r.TotalItems > 0)
+		})
+	}
+}
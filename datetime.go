@@ -0,0 +1,57 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateTimeLayout is the format of the dates sent by PocketBase, e.g. "2024-05-01 10:00:00.000Z".
+const DateTimeLayout = "2006-01-02 15:04:05.000Z07:00"
+
+// DateTime is a date field of a record. An empty date is the zero time and marshals back to "".
+// String formats the date for filters, e.g. fmt.Sprintf("created >= '%s'", since).
+type DateTime struct {
+	time.Time
+}
+
+func NewDateTime(t time.Time) DateTime {
+	return DateTime{Time: t}
+}
+
+func (d DateTime) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.UTC().Format(DateTimeLayout)
+}
+
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *DateTime) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("[datetime] can't unmarshal %s, err %w", data, err)
+	}
+	parsed, err := parseDateTime(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// parseDateTime parses a date in the PocketBase or RFC 3339 format, an empty value is the zero date.
+func parseDateTime(value string) (DateTime, error) {
+	if value == "" {
+		return DateTime{}, nil
+	}
+	for _, layout := range []string{DateTimeLayout, time.DateTime + "Z07:00", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return DateTime{Time: t}, nil
+		}
+	}
+	return DateTime{}, fmt.Errorf("[datetime] can't parse %q", value)
+}
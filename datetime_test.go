@@ -0,0 +1,99 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "PocketBase format",
+			json: `"2024-05-01 10:00:00.123Z"`,
+			want: time.Date(2024, 5, 1, 10, 0, 0, 123_000_000, time.UTC),
+		},
+		{
+			name: "Without milliseconds",
+			json: `"2024-05-01 10:00:00Z"`,
+			want: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "RFC 3339",
+			json: `"2024-05-01T10:00:00Z"`,
+			want: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Empty",
+			json: `""`,
+		},
+		{
+			name:    "Malformed",
+			json:    `"yesterday"`,
+			wantErr: true,
+		},
+		{
+			name:    "Not a string",
+			json:    `123`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d DateTime
+			err := json.Unmarshal([]byte(tt.json), &d)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(d.Time), "got %v", d.Time)
+		})
+	}
+
+	t.Run("marshal", func(t *testing.T) {
+		data, err := json.Marshal(struct {
+			Set   DateTime `json:"set"`
+			Unset DateTime `json:"unset"`
+		}{
+			Set: NewDateTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))),
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"set":"2024-05-01 10:00:00.000Z","unset":""}`, string(data))
+	})
+}
+
+func TestBaseRecord(t *testing.T) {
+	type post struct {
+		BaseRecord
+		Title string `json:"title"`
+	}
+
+	var p post
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "p1",
+		"collectionId": "c1",
+		"collectionName": "posts",
+		"created": "2024-05-01 10:00:00.000Z",
+		"updated": "",
+		"title": "hello"
+	}`), &p))
+	assert.Equal(t, "p1", p.ID)
+	assert.Equal(t, "posts", p.CollectionName)
+	assert.Equal(t, "hello", p.Title)
+	assert.True(t, p.Updated.IsZero())
+	assert.Equal(t, "created >= '2024-05-01 10:00:00.000Z'", fmt.Sprintf("created >= '%s'", p.Created))
+
+	data, err := json.Marshal(post{Title: "new"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"created":"","updated":"","title":"new"}`, string(data))
+}
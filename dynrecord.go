@@ -0,0 +1,186 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// DynRecord is a record of any collection, as returned by the untyped Client methods.
+// The getters convert the values like PocketBase does on the server and return the zero value
+// for missing or unconvertible values, so unknown collections can be handled safely.
+//
+// Example:
+//
+//	response, err := client.List("posts", pocketbase.ParamsList{Expand: "author"})
+//	for _, r := range response.Items {
+//		log.Print(r.GetString("title"), r.GetInt("views"), r.GetDateTime("created"))
+//		for _, author := range r.GetExpand("author") {
+//			log.Print(author.GetString("name"))
+//		}
+//	}
+type DynRecord map[string]any
+
+// Get returns the raw value, nil if it's missing.
+func (r DynRecord) Get(key string) any {
+	return r[key]
+}
+
+// Set changes the value, e.g. before sending the record with Client.Update.
+func (r DynRecord) Set(key string, value any) {
+	r[key] = value
+}
+
+func (r DynRecord) GetString(key string) string {
+	return toString(r[key])
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return ""
+	}
+}
+
+// GetInt returns the number truncated to an int.
+func (r DynRecord) GetInt(key string) int {
+	return int(r.GetFloat(key))
+}
+
+func (r DynRecord) GetFloat(key string) float64 {
+	switch v := r[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+func (r DynRecord) GetBool(key string) bool {
+	switch v := r[key].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	default:
+		return r.GetFloat(key) != 0
+	}
+}
+
+func (r DynRecord) GetDateTime(key string) DateTime {
+	switch v := r[key].(type) {
+	case DateTime:
+		return v
+	case time.Time:
+		return NewDateTime(v)
+	case string:
+		var d DateTime
+		data, _ := json.Marshal(v)
+		_ = d.UnmarshalJSON(data)
+		return d
+	default:
+		return DateTime{}
+	}
+}
+
+// GetStringSlice returns the values of a multiple select, relation or file field.
+// A single non-empty value is returned as a one element slice.
+func (r DynRecord) GetStringSlice(key string) []string {
+	switch v := r[key].(type) {
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := toString(item); s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	case nil:
+		return nil
+	default:
+		if s := r.GetString(key); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+}
+
+// GetExpand returns the expanded records of a relation, see ParamsList.Expand.
+// A single relation is returned as a one element slice.
+func (r DynRecord) GetExpand(key string) []DynRecord {
+	expand, _ := toDynRecord(r["expand"])
+	switch v := expand[key].(type) {
+	case []any:
+		records := make([]DynRecord, 0, len(v))
+		for _, item := range v {
+			if record, ok := toDynRecord(item); ok {
+				records = append(records, record)
+			}
+		}
+		return records
+	case []DynRecord:
+		return v
+	default:
+		if record, ok := toDynRecord(v); ok {
+			return []DynRecord{record}
+		}
+		return nil
+	}
+}
+
+func toDynRecord(value any) (DynRecord, bool) {
+	switch v := value.(type) {
+	case DynRecord:
+		return v, true
+	case map[string]any:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// Unmarshal decodes the record into a struct, including the fields tagged with pb:"expand=...".
+func (r DynRecord) Unmarshal(into any) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, into); err != nil {
+		return err
+	}
+	if v := reflect.ValueOf(into); v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Struct {
+		return DecodeExpand(data, into)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynRecord(t *testing.T) {
+	var r DynRecord
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "p1",
+		"title": "hello",
+		"views": 42.7,
+		"published": true,
+		"count": "12",
+		"flag": "true",
+		"created": "2024-05-01 10:00:00.000Z",
+		"tags": ["t1", "t2"],
+		"author": "u1",
+		"empty": "",
+		"expand": {
+			"author": {"id": "u1", "name": "jane"},
+			"tags": [{"id": "t1", "name": "go"}, {"id": "t2", "name": "web"}]
+		}
+	}`), &r))
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"GetString", r.GetString("title"), "hello"},
+		{"GetString of a number", r.GetString("views"), "42.7"},
+		{"GetString of a bool", r.GetString("published"), "true"},
+		{"GetString of a missing value", r.GetString("missing"), ""},
+		{"GetInt", r.GetInt("views"), 42},
+		{"GetInt of a string", r.GetInt("count"), 12},
+		{"GetInt of a bool", r.GetInt("published"), 1},
+		{"GetInt of text", r.GetInt("title"), 0},
+		{"GetFloat", r.GetFloat("views"), 42.7},
+		{"GetBool", r.GetBool("published"), true},
+		{"GetBool of a string", r.GetBool("flag"), true},
+		{"GetBool of a number", r.GetBool("views"), true},
+		{"GetBool of a missing value", r.GetBool("missing"), false},
+		{"GetStringSlice", r.GetStringSlice("tags"), []string{"t1", "t2"}},
+		{"GetStringSlice of a single value", r.GetStringSlice("author"), []string{"u1"}},
+		{"GetStringSlice of an empty value", r.GetStringSlice("empty"), []string(nil)},
+		{"GetStringSlice of a missing value", r.GetStringSlice("missing"), []string(nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.got)
+		})
+	}
+
+	t.Run("GetDateTime", func(t *testing.T) {
+		assert.True(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Equal(r.GetDateTime("created").Time))
+		assert.True(t, r.GetDateTime("title").IsZero())
+		assert.True(t, r.GetDateTime("missing").IsZero())
+	})
+
+	t.Run("GetExpand", func(t *testing.T) {
+		authors := r.GetExpand("author")
+		require.Len(t, authors, 1)
+		assert.Equal(t, "jane", authors[0].GetString("name"))
+
+		tags := r.GetExpand("tags")
+		require.Len(t, tags, 2)
+		assert.Equal(t, "web", tags[1].GetString("name"))
+
+		assert.Nil(t, r.GetExpand("missing"))
+		assert.Nil(t, DynRecord{}.GetExpand("author"))
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		r := DynRecord{}
+		r.Set("title", "new")
+		assert.Equal(t, "new", r.Get("title"))
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"title":"new"}`, string(data))
+	})
+
+	t.Run("Unmarshal", func(t *testing.T) {
+		var post struct {
+			BaseRecord
+			Title  string   `json:"title"`
+			Tags   []string `json:"tags"`
+			Author *struct {
+				Name string `json:"name"`
+			} `json:"-" pb:"expand=author"`
+		}
+		require.NoError(t, r.Unmarshal(&post))
+		assert.Equal(t, "p1", post.ID)
+		assert.Equal(t, "hello", post.Title)
+		assert.Equal(t, []string{"t1", "t2"}, post.Tags)
+		assert.False(t, post.Created.IsZero())
+		require.NotNil(t, post.Author)
+		assert.Equal(t, "jane", post.Author.Name)
+
+		var m map[string]any
+		require.NoError(t, r.Unmarshal(&m))
+		assert.Equal(t, "hello", m["title"])
+
+		var wrong struct {
+			Title int `json:"title"`
+		}
+		assert.Error(t, r.Unmarshal(&wrong))
+	})
+}
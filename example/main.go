@@ -5,7 +5,6 @@ import (
 	"log"
 	"time"
 
-	"github.com/mitchellh/mapstructure"
 	"github.com/zcharym/pocketbase-client"
 )
 
@@ -22,6 +21,7 @@ func main() {
 	// Other configuration options:
 	// pocketbase.WithAdminEmailPassword("admin@admin.com", "admin@admin.com")
 	// pocketbase.WithUserEmailPassword("user@user.com", "user@user.com")
+	// pocketbase.WithAdminCredentials(pocketbase.EnvCredentials("PB_EMAIL", "PB_PASSWORD"))
 	// pocketbase.WithUserToken(token)
 	// pocketbase.WithAdminToken(token)
 	// pocketbase.WithDebug()
@@ -38,7 +38,7 @@ func main() {
 	log.Printf("Total items: %d, total pages: %d\n", response.TotalItems, response.TotalPages)
 	for _, item := range response.Items {
 		var test Post
-		err := mapstructure.Decode(item, &test)
+		err := item.Unmarshal(&test)
 		errs = errors.Join(errs, err)
 
 		log.Printf("Item: %#v\n", test)
@@ -0,0 +1,216 @@
+package pocketbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var ErrExpandTarget = errors.New("expand target must be a pointer to a struct")
+
+// Expanded is a single expanded relation in the expand object of a record.
+// It accepts a record or a list of records (the first is used), so it survives a relation
+// changed to multiple values.
+//
+// Example:
+//
+//	type Post struct {
+//		pocketbase.BaseRecord
+//		Author string `json:"author"`
+//		Expand struct {
+//			Author   pocketbase.Expanded[User]        `json:"author"`
+//			Comments pocketbase.ExpandedMany[Comment] `json:"comments_via_post"`
+//		} `json:"expand"`
+//	}
+type Expanded[T any] struct {
+	Record T
+	// Valid is false when the relation wasn't expanded, e.g. it's empty or not listed in ParamsList.Expand.
+	Valid bool
+}
+
+// Get returns the expanded record and whether it was expanded.
+func (e Expanded[T]) Get() (T, bool) {
+	return e.Record, e.Valid
+}
+
+func (e Expanded[T]) MarshalJSON() ([]byte, error) {
+	if !e.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(e.Record)
+}
+
+func (e *Expanded[T]) UnmarshalJSON(data []byte) error {
+	var record T
+	e.Record, e.Valid = record, false
+
+	records, err := splitRecords(data)
+	if err != nil || len(records) == 0 {
+		return err
+	}
+	if err := json.Unmarshal(records[0], &e.Record); err != nil {
+		return err
+	}
+	e.Valid = true
+	return nil
+}
+
+// ExpandedMany is a multiple or back-relation ("comments_via_post") in the expand object of a record.
+// It accepts a list of records or a single record.
+type ExpandedMany[T any] []T
+
+func (e *ExpandedMany[T]) UnmarshalJSON(data []byte) error {
+	records, err := splitRecords(data)
+	if err != nil {
+		return err
+	}
+	*e = nil
+	for _, raw := range records {
+		var record T
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return err
+		}
+		*e = append(*e, record)
+	}
+	return nil
+}
+
+// splitRecords returns the records of an expanded relation: none for null, one for an object
+// or the elements of a list.
+func splitRecords(data []byte) ([]json.RawMessage, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0 || bytes.Equal(data, []byte("null")):
+		return nil, nil
+	case data[0] == '[':
+		var records []json.RawMessage
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, err
+		}
+		return records, nil
+	default:
+		return []json.RawMessage{data}, nil
+	}
+}
+
+type expandField struct {
+	index []int
+	path  []string
+}
+
+// expandFields caches the fields tagged with pb:"expand=..." by struct type.
+var expandFields sync.Map
+
+func expandFieldsOf(typ reflect.Type) []expandField {
+	if fields, ok := expandFields.Load(typ); ok {
+		return fields.([]expandField)
+	}
+
+	var fields []expandField
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() {
+			continue
+		}
+		for _, option := range strings.Split(f.Tag.Get("pb"), ",") {
+			if path, ok := strings.CutPrefix(strings.TrimSpace(option), "expand="); ok && path != "" {
+				fields = append(fields, expandField{index: f.Index, path: strings.Split(path, ".")})
+			}
+		}
+	}
+	expandFields.Store(typ, fields)
+	return fields
+}
+
+// DecodeExpand decodes the expanded relations of a raw record into the fields of dst tagged with
+// pb:"expand=path". The path is a relation name, a back-relation ("comments_via_post") or nested relations
+// ("author.profile"), like in ParamsList.Expand. Slice fields receive all expanded records, other fields
+// the first one. The tagged fields should be ignored by encoding/json with json:"-".
+// Collection decodes the tagged fields of its records automatically.
+//
+// Example:
+//
+//	type Post struct {
+//		pocketbase.BaseRecord
+//		Author   *User     `json:"-" pb:"expand=author"`
+//		Profile  *Profile  `json:"-" pb:"expand=author.profile"`
+//		Comments []Comment `json:"-" pb:"expand=comments_via_post"`
+//	}
+func DecodeExpand(raw json.RawMessage, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrExpandTarget
+	}
+	v = v.Elem()
+
+	fields := expandFieldsOf(v.Type())
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, f := range fields {
+		records, err := expandPath([]json.RawMessage{raw}, f.path)
+		if err != nil {
+			return fmt.Errorf("[expand] can't decode %s, err %w", strings.Join(f.path, "."), err)
+		}
+		if err := setExpanded(v.FieldByIndex(f.index), records); err != nil {
+			return fmt.Errorf("[expand] can't decode %s, err %w", strings.Join(f.path, "."), err)
+		}
+	}
+	return nil
+}
+
+// expandPath follows the path through the expand objects of the records.
+func expandPath(records []json.RawMessage, path []string) ([]json.RawMessage, error) {
+	for _, name := range path {
+		var next []json.RawMessage
+		for _, raw := range records {
+			var record struct {
+				Expand map[string]json.RawMessage `json:"expand"`
+			}
+			if err := json.Unmarshal(raw, &record); err != nil {
+				return nil, err
+			}
+			expanded, err := splitRecords(record.Expand[name])
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, expanded...)
+		}
+		records = next
+	}
+	return records, nil
+}
+
+func setExpanded(field reflect.Value, records []json.RawMessage) error {
+	field.SetZero()
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(records), len(records))
+		for i, raw := range records {
+			if err := json.Unmarshal(raw, slice.Index(i).Addr().Interface()); err != nil {
+				return err
+			}
+		}
+		if len(records) > 0 {
+			field.Set(slice)
+		}
+		return nil
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return json.Unmarshal(records[0], field.Addr().Interface())
+}
+
+// decodeRecord unmarshals a record into dst including the fields tagged with pb:"expand=...".
+func decodeRecord[T any](data []byte, dst *T) error {
+	if err := json.Unmarshal(data, dst); err != nil {
+		return err
+	}
+	if reflect.TypeOf(dst).Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return DecodeExpand(data, dst)
+}
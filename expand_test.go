@@ -0,0 +1,168 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const expandedPost = `{
+	"id": "p1",
+	"title": "hello",
+	"author": "u1",
+	"tags": ["t1", "t2"],
+	"expand": {
+		"author": {
+			"id": "u1",
+			"name": "jane",
+			"profile": "pr1",
+			"expand": {"profile": {"id": "pr1", "bio": "writer"}}
+		},
+		"tags": [{"id": "t1", "name": "go"}, {"id": "t2", "name": "web"}],
+		"comments_via_post": [
+			{"id": "c1", "text": "first", "expand": {"user": {"id": "u2", "name": "joe"}}},
+			{"id": "c2", "text": "second", "expand": {"user": {"id": "u1", "name": "jane"}}}
+		]
+	}
+}`
+
+type expandUser struct {
+	ID      string                            `json:"id"`
+	Name    string                            `json:"name"`
+	Profile string                            `json:"profile"`
+	Expand  *struct{ Profile *expandProfile } `json:"expand,omitempty"`
+}
+
+type expandProfile struct {
+	ID  string `json:"id"`
+	Bio string `json:"bio"`
+}
+
+type expandTag struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type expandComment struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+type taggedPost struct {
+	ID        string          `json:"id"`
+	Title     string          `json:"title"`
+	Author    *expandUser     `json:"-" pb:"expand=author"`
+	Profile   expandProfile   `json:"-" pb:"expand=author.profile"`
+	Tags      []expandTag     `json:"-" pb:"expand=tags"`
+	FirstTag  *expandTag      `json:"-" pb:"expand=tags"`
+	Comments  []expandComment `json:"-" pb:"expand=comments_via_post"`
+	Commenter []expandUser    `json:"-" pb:"expand=comments_via_post.user"`
+	Missing   []expandTag     `json:"-" pb:"expand=missing.relation"`
+}
+
+func TestDecodeExpand(t *testing.T) {
+	var post taggedPost
+	require.NoError(t, decodeRecord([]byte(expandedPost), &post))
+
+	assert.Equal(t, "hello", post.Title)
+	require.NotNil(t, post.Author)
+	assert.Equal(t, "jane", post.Author.Name)
+	assert.Equal(t, expandProfile{ID: "pr1", Bio: "writer"}, post.Profile)
+	assert.Equal(t, []expandTag{{ID: "t1", Name: "go"}, {ID: "t2", Name: "web"}}, post.Tags)
+	assert.Equal(t, &expandTag{ID: "t1", Name: "go"}, post.FirstTag)
+	assert.Equal(t, []expandComment{{ID: "c1", Text: "first"}, {ID: "c2", Text: "second"}}, post.Comments)
+	assert.Equal(t, []expandUser{{ID: "u2", Name: "joe"}, {ID: "u1", Name: "jane"}}, post.Commenter)
+	assert.Nil(t, post.Missing)
+
+	t.Run("not expanded", func(t *testing.T) {
+		post := taggedPost{Tags: []expandTag{{ID: "stale"}}}
+		require.NoError(t, DecodeExpand(json.RawMessage(`{"id":"p1"}`), &post))
+		assert.Nil(t, post.Author)
+		assert.Nil(t, post.Tags)
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		var post taggedPost
+		assert.ErrorIs(t, DecodeExpand(json.RawMessage(expandedPost), post), ErrExpandTarget)
+		var m map[string]any
+		assert.ErrorIs(t, DecodeExpand(json.RawMessage(expandedPost), &m), ErrExpandTarget)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		var post struct {
+			Author []string `json:"-" pb:"expand=author"`
+		}
+		assert.ErrorContains(t, DecodeExpand(json.RawMessage(expandedPost), &post), "[expand] can't decode author")
+	})
+}
+
+func TestExpanded(t *testing.T) {
+	type post struct {
+		Expand struct {
+			Author   Expanded[expandUser]        `json:"author"`
+			Tags     ExpandedMany[expandTag]     `json:"tags"`
+			Comments ExpandedMany[expandComment] `json:"comments_via_post"`
+			Missing  Expanded[expandUser]        `json:"missing"`
+		} `json:"expand"`
+	}
+
+	var p post
+	require.NoError(t, json.Unmarshal([]byte(expandedPost), &p))
+	author, ok := p.Expand.Author.Get()
+	assert.True(t, ok)
+	assert.Equal(t, "jane", author.Name)
+	require.NotNil(t, author.Expand)
+	assert.Equal(t, &expandProfile{ID: "pr1", Bio: "writer"}, author.Expand.Profile)
+	assert.Len(t, p.Expand.Tags, 2)
+	assert.Len(t, p.Expand.Comments, 2)
+	assert.False(t, p.Expand.Missing.Valid)
+
+	t.Run("relation changed to multiple values", func(t *testing.T) {
+		var single Expanded[expandTag]
+		require.NoError(t, json.Unmarshal([]byte(`[{"id":"t1"},{"id":"t2"}]`), &single))
+		assert.Equal(t, Expanded[expandTag]{Record: expandTag{ID: "t1"}, Valid: true}, single)
+
+		var many ExpandedMany[expandTag]
+		require.NoError(t, json.Unmarshal([]byte(`{"id":"t1"}`), &many))
+		assert.Equal(t, ExpandedMany[expandTag]{{ID: "t1"}}, many)
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		data, err := json.Marshal(p.Expand.Missing)
+		require.NoError(t, err)
+		assert.Equal(t, "null", string(data))
+		data, err = json.Marshal(Expanded[expandTag]{Record: expandTag{ID: "t1"}, Valid: true})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"t1","name":""}`, string(data))
+	})
+}
+
+func TestCollection_Expand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "author.profile,tags,comments_via_post.user", r.URL.Query().Get("expand"))
+		if r.URL.Path == "/api/collections/posts/records" {
+			_, _ = w.Write([]byte(`{"page":1,"perPage":30,"totalItems":1,"totalPages":1,"items":[` + expandedPost + `]}`))
+			return
+		}
+		_, _ = w.Write([]byte(expandedPost))
+	}))
+	defer server.Close()
+
+	collection := CollectionSet[taggedPost](NewClient(server.URL), "posts")
+	params := ParamsList{Expand: "author.profile,tags,comments_via_post.user"}
+
+	list, err := collection.List(params)
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.TotalItems)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "writer", list.Items[0].Profile.Bio)
+	assert.Len(t, list.Items[0].Commenter, 2)
+
+	one, err := collection.OneWithParams("p1", params)
+	require.NoError(t, err)
+	assert.Equal(t, list.Items[0], one)
+}
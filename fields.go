@@ -0,0 +1,217 @@
+package pocketbase
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxExpandDepth is the nesting limit of expanded relations in PocketBase.
+const maxExpandDepth = 6
+
+// expandedRecord is implemented by Expanded and ExpandedMany to report the record type.
+type expandedRecord interface {
+	expandedType() reflect.Type
+}
+
+func (Expanded[T]) expandedType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (ExpandedMany[T]) expandedType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+type derivedParams struct {
+	fields string
+	expand string
+}
+
+// derivedParamsCache caches the params derived by record type.
+var derivedParamsCache sync.Map
+
+// WithDerivedParams returns a copy of the collection, which derives ParamsList.Fields and ParamsList.Expand
+// from the struct tags of T when they're empty, so only the fields T can hold are fetched:
+//   - the json names of the fields, including the fields of embedded structs like BaseRecord
+//   - pb:"excerpt=N" requests an excerpt of N characters, pb:"excerpt=N,ellipsis" ends it with "..."
+//   - pb:"expand=path" fields (see DecodeExpand) and the fields of an "expand" struct with
+//     Expanded or ExpandedMany fields are expanded, and their fields are derived as well
+//
+// It applies to the reads and to the records returned by CreateWithParams, UpdateWithParams, Patch,
+// UpdateFields, UpdateIfUnchanged and RetryOnConflict.
+//
+// Example:
+//
+//	type Post struct {
+//		pocketbase.BaseRecord
+//		Title   string `json:"title"`
+//		Content string `json:"content" pb:"excerpt=200,ellipsis"`
+//		Author  *User  `json:"-" pb:"expand=author"`
+//	}
+//
+//	posts := pocketbase.CollectionSet[Post](client, "posts").WithDerivedParams()
+//	// fields=id,collectionId,collectionName,created,updated,title,content:excerpt(200,true),expand.author.id,...
+//	// expand=author
+//	list, err := posts.List(pocketbase.ParamsList{Page: 1, Size: 20})
+func (c *Collection[T]) WithDerivedParams() *Collection[T] {
+	derived := *c
+	derived.deriveParams = true
+	return &derived
+}
+
+// params fills the empty fields and expand params derived from T.
+func (c *Collection[T]) params(params ParamsList) ParamsList {
+	if !c.deriveParams {
+		return params
+	}
+	d := derivedParamsOf(reflect.TypeOf((*T)(nil)).Elem())
+	if params.Fields == "" {
+		params.Fields = d.fields
+	}
+	if params.Expand == "" {
+		params.Expand = d.expand
+	}
+	return params
+}
+
+func derivedParamsOf(typ reflect.Type) derivedParams {
+	if d, ok := derivedParamsCache.Load(typ); ok {
+		return d.(derivedParams)
+	}
+	fields, expand := deriveFields(typ, nil)
+	d := derivedParams{
+		fields: strings.Join(unique(fields), ","),
+		expand: strings.Join(unique(expand), ","),
+	}
+	derivedParamsCache.Store(typ, d)
+	return d
+}
+
+// deriveFields returns the fields and the expand paths of a record type. The relations to the types
+// of the outer records are skipped, so recursive types don't expand in circles.
+func deriveFields(typ reflect.Type, outer []reflect.Type) (fields []string, expand []string) {
+	typ = indirect(typ)
+	if typ.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	outer = append(outer[:len(outer):len(outer)], typ)
+
+	relation := func(path string, typ reflect.Type) {
+		typ = recordType(typ)
+		if len(outer)+strings.Count(path, ".") > maxExpandDepth || slices.Contains(outer, typ) {
+			return
+		}
+		expand = append(expand, path)
+		relFields, relExpand := deriveFields(typ, outer)
+		prefix := "expand." + strings.ReplaceAll(path, ".", ".expand.") + "."
+		for _, f := range relFields {
+			fields = append(fields, prefix+f)
+		}
+		for _, e := range relExpand {
+			expand = append(expand, path+"."+e)
+		}
+	}
+
+	for _, f := range reflect.VisibleFields(typ) {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || (f.Anonymous && name == "") {
+			continue
+		}
+		if len(f.Index) > 1 && !promoted(typ, f.Index) {
+			continue
+		}
+
+		var excerpt, ellipsis string
+		for _, option := range strings.Split(f.Tag.Get("pb"), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+			switch key {
+			case "expand":
+				if value != "" {
+					relation(value, f.Type)
+				}
+			case "excerpt":
+				excerpt = value
+			case "ellipsis":
+				ellipsis = ",true"
+			}
+		}
+
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		if name == "expand" {
+			if expandType := indirect(f.Type); expandType.Kind() == reflect.Struct && !isExpanded(expandType) {
+				for _, rel := range reflect.VisibleFields(expandType) {
+					relName, _, _ := strings.Cut(rel.Tag.Get("json"), ",")
+					if !rel.IsExported() || rel.Anonymous || relName == "-" {
+						continue
+					}
+					if relName == "" {
+						relName = rel.Name
+					}
+					relation(relName, rel.Type)
+				}
+				continue
+			}
+		}
+		if excerpt != "" {
+			name += ":excerpt(" + excerpt + ellipsis + ")"
+		}
+		fields = append(fields, name)
+	}
+	return fields, expand
+}
+
+// promoted reports whether encoding/json promotes the nested field, which it does through untagged embedded structs.
+func promoted(typ reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		f := indirect(typ).Field(i)
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); !f.Anonymous || name != "" {
+			return false
+		}
+		typ = f.Type
+	}
+	return true
+}
+
+// recordType returns the record type of a relation field: *T, []T, Expanded[T] or ExpandedMany[T].
+func recordType(typ reflect.Type) reflect.Type {
+	for {
+		typ = indirect(typ)
+		if isExpanded(typ) {
+			typ = reflect.Zero(typ).Interface().(expandedRecord).expandedType()
+			continue
+		}
+		if kind := typ.Kind(); kind != reflect.Slice && kind != reflect.Array {
+			return typ
+		}
+		typ = typ.Elem()
+	}
+}
+
+func isExpanded(typ reflect.Type) bool {
+	return typ.Implements(reflect.TypeOf((*expandedRecord)(nil)).Elem())
+}
+
+func indirect(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := values[:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,154 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type derivedProfile struct {
+	ID  string `json:"id"`
+	Bio string `json:"bio" pb:"excerpt=20"`
+}
+
+type derivedUser struct {
+	ID      string          `json:"id"`
+	Name    string          `json:"name"`
+	Profile *derivedProfile `json:"-" pb:"expand=profile"`
+	// recursive relation, skipped when deriving
+	Posts []derivedPost `json:"-" pb:"expand=posts_via_author"`
+}
+
+type derivedPost struct {
+	BaseRecord
+	Title   string          `json:"title"`
+	Content string          `json:"content,omitempty" pb:"excerpt=200,ellipsis"`
+	Draft   bool            `json:"draft"`
+	secret  string          //nolint:unused
+	Ignored string          `json:"-"`
+	Author  *derivedUser    `json:"-" pb:"expand=author"`
+	Tags    []derivedTag    `json:"-" pb:"expand=tags"`
+	Meta    json.RawMessage `json:"meta"`
+}
+
+type derivedTag struct {
+	Name string `json:"name"`
+}
+
+func TestDeriveParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		typ        reflect.Type
+		wantFields string
+		wantExpand string
+	}{
+		{
+			name:       "Tags",
+			typ:        reflect.TypeOf(derivedPost{}),
+			wantFields: "id,collectionId,collectionName,created,updated,title,content:excerpt(200,true),draft,expand.author.id,expand.author.name,expand.author.expand.profile.id,expand.author.expand.profile.bio:excerpt(20),expand.tags.name,meta",
+			wantExpand: "author,author.profile,tags",
+		},
+		{
+			name: "Expand struct",
+			typ: reflect.TypeOf(struct {
+				ID     string `json:"id"`
+				Expand struct {
+					Author   Expanded[derivedProfile] `json:"author"`
+					Comments ExpandedMany[derivedTag] `json:"comments_via_post"`
+					Editors  []derivedTag             `json:"editors"`
+					Reviewer *Expanded[derivedTag]    `json:"reviewer"`
+				} `json:"expand"`
+			}{}),
+			wantFields: "id,expand.author.id,expand.author.bio:excerpt(20),expand.comments_via_post.name,expand.editors.name,expand.reviewer.name",
+			wantExpand: "author,comments_via_post,editors,reviewer",
+		},
+		{
+			name: "Embedded struct with a json name",
+			typ: reflect.TypeOf(struct {
+				BaseRecord `json:"base"`
+			}{}),
+			wantFields: "base",
+		},
+		{
+			name: "Map",
+			typ:  reflect.TypeOf(map[string]any{}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, expand := deriveFields(tt.typ, nil)
+			assert.Equal(t, tt.wantFields, joinUnique(fields))
+			assert.Equal(t, tt.wantExpand, joinUnique(expand))
+		})
+	}
+}
+
+func joinUnique(values []string) string {
+	return strings.Join(unique(values), ",")
+}
+
+func TestCollection_WithDerivedParams(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		queries []url.Values
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query())
+		mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/api/collections/posts/records/p1":
+			_, _ = w.Write([]byte(`{"id":"p1","title":"hello"}`))
+		case r.URL.Query().Get("filter") == "title = 'none'":
+			_, _ = w.Write([]byte(`{"page":1,"perPage":1,"totalItems":0,"totalPages":0,"items":[]}`))
+		default:
+			_, _ = w.Write([]byte(`{"page":1,"perPage":1,"totalItems":1,"totalPages":1,"items":[{"id":"p1","title":"hello"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	plain := CollectionSet[derivedPost](NewClient(server.URL), "posts")
+	derived := plain.WithDerivedParams()
+	want := derivedParamsOf(reflect.TypeOf(derivedPost{}))
+
+	_, err := derived.List(ParamsList{})
+	require.NoError(t, err)
+	_, err = derived.FullList(ParamsList{})
+	require.NoError(t, err)
+	post, err := derived.One("p1")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", post.Title)
+	first, err := derived.First(ParamsList{Filters: "title = 'hello'", Sort: "-created"})
+	require.NoError(t, err)
+	assert.Equal(t, "p1", first.ID)
+
+	_, err = derived.First(ParamsList{Filters: "title = 'none'"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = derived.OneWithParams("p1", ParamsList{Fields: "id", Expand: "tags"})
+	require.NoError(t, err)
+	_, err = plain.One("p1")
+	require.NoError(t, err)
+
+	require.Len(t, queries, 7)
+	for _, q := range queries[:5] {
+		assert.Equal(t, want.fields, q.Get("fields"))
+		assert.Equal(t, want.expand, q.Get("expand"))
+	}
+	assert.Equal(t, "1", queries[3].Get("perPage"))
+	assert.Equal(t, "-created", queries[3].Get("sort"))
+	// explicit params win
+	assert.Equal(t, "id", queries[5].Get("fields"))
+	assert.Equal(t, "tags", queries[5].Get("expand"))
+	// the original collection doesn't derive
+	assert.False(t, queries[6].Has("fields"))
+	assert.False(t, queries[6].Has("expand"))
+}
@@ -5,10 +5,8 @@ go 1.22.3
 require (
 	github.com/SierraSoftworks/multicast/v2 v2.0.0
 	github.com/cenkalti/backoff/v4 v4.3.0
-	github.com/donovanhide/eventsource v0.0.0-20210830082556-c59027999da0
 	github.com/duke-git/lancet/v2 v2.3.0
 	github.com/go-resty/resty/v2 v2.12.0
-	github.com/mitchellh/mapstructure v1.5.0
 	github.com/pocketbase/dbx v1.10.1
 	github.com/pocketbase/pocketbase v0.22.12
 	github.com/stretchr/testify v1.9.0
@@ -0,0 +1,92 @@
+package pocketbase
+
+// SubscribeRecord subscribes to the changes of a single record of the collection.
+func (c *Collection[T]) SubscribeRecord(id string) (*Stream[T], error) {
+	return c.Subscribe(c.Name + "/" + id)
+}
+
+// Filter drops the events not matching the predicate before they're delivered.
+// Events are held back until the first Events call or handler registration,
+// so a filter set right after subscribing applies to all events.
+//
+// Example:
+//
+//	stream.Filter(func(e pocketbase.Event[Post]) bool {
+//		return e.Record.Published
+//	})
+func (s *Stream[T]) Filter(pred func(Event[T]) bool) *Stream[T] {
+	if pred == nil {
+		s.filter.Store(nil)
+		return s
+	}
+	s.filter.Store(&pred)
+	return s
+}
+
+// OnCreate registers a handler called for every created record.
+// Handlers are an alternative to reading the Events channel, they're called
+// one at a time in the order the events were received.
+//
+// Example:
+//
+//	stream.
+//		OnCreate(func(p Post) { log.Println("created", p.ID) }).
+//		OnDelete(func(p Post) { log.Println("deleted", p.ID) })
+func (s *Stream[T]) OnCreate(fn func(T)) *Stream[T] {
+	return s.on(ActionCreate, fn)
+}
+
+// OnUpdate registers a handler called for every updated record, see OnCreate.
+func (s *Stream[T]) OnUpdate(fn func(T)) *Stream[T] {
+	return s.on(ActionUpdate, fn)
+}
+
+// OnDelete registers a handler called for every deleted record, see OnCreate.
+func (s *Stream[T]) OnDelete(fn func(T)) *Stream[T] {
+	return s.on(ActionDelete, fn)
+}
+
+// OnError registers a handler called for events which couldn't be decoded or caught up, see OnCreate.
+func (s *Stream[T]) OnError(fn func(error)) *Stream[T] {
+	s.handlersMu.Lock()
+	s.errHandlers = append(s.errHandlers, fn)
+	s.handlersMu.Unlock()
+
+	s.startHandlers()
+	return s
+}
+
+func (s *Stream[T]) on(action string, fn func(T)) *Stream[T] {
+	s.handlersMu.Lock()
+	s.handlers[action] = append(s.handlers[action], fn)
+	s.handlersMu.Unlock()
+
+	s.startHandlers()
+	return s
+}
+
+func (s *Stream[T]) startHandlers() {
+	s.onceHandlers.Do(func() {
+		events := s.Events()
+		go func() {
+			for e := range events {
+				s.handle(e)
+			}
+		}()
+	})
+}
+
+func (s *Stream[T]) handle(e Event[T]) {
+	s.handlersMu.RLock()
+	defer s.handlersMu.RUnlock()
+
+	if e.Error != nil {
+		for _, fn := range s.errHandlers {
+			fn(e.Error)
+		}
+		return
+	}
+	for _, fn := range s.handlers[e.Action] {
+		fn(e.Record)
+	}
+}
@@ -0,0 +1,89 @@
+package pocketbase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollection_SubscribeRecord(t *testing.T) {
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.SubscribeRecord("abc")
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+
+	server.mu.Lock()
+	assert.Equal(t, []string{"posts/abc"}, server.subscriptions["client1"])
+	server.mu.Unlock()
+
+	server.conn(t).send("posts/abc", `{"action":"update","record":{"id":"abc"}}`)
+	e := <-stream.Events()
+	assert.Equal(t, ActionUpdate, e.Action)
+	assert.Equal(t, "abc", e.Record["id"])
+}
+
+func TestStream_Filter(t *testing.T) {
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.Subscribe()
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+
+	// events received before the first listener are filtered too
+	conn := server.conn(t)
+	conn.send("posts", `{"action":"create","record":{"n":1,"public":false}}`)
+	conn.send("posts", `{"action":"create","record":{"n":2,"public":true}}`)
+
+	ch := stream.Filter(func(e Event[map[string]any]) bool {
+		return e.Record["public"] == true
+	}).Events()
+	conn.send("posts", `{"action":"update","record":{"n":3,"public":false}}`)
+	conn.send("posts", `{"action":"update","record":{"n":4,"public":true}}`)
+
+	assert.Equal(t, float64(2), (<-ch).Record["n"])
+	assert.Equal(t, float64(4), (<-ch).Record["n"])
+}
+
+func TestStream_Handlers(t *testing.T) {
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.Subscribe()
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+
+	calls := make(chan string, 8)
+	stream.
+		OnCreate(func(r map[string]any) { calls <- "create " + r["id"].(string) }).
+		OnUpdate(func(r map[string]any) { calls <- "update " + r["id"].(string) }).
+		OnDelete(func(r map[string]any) { calls <- "delete " + r["id"].(string) }).
+		OnError(func(err error) { calls <- "error" })
+
+	conn := server.conn(t)
+	conn.send("posts", `{"action":"create","record":{"id":"a"}}`)
+	conn.send("posts", `{"action":"update","record":{"id":"a"}}`)
+	conn.send("posts", `{"action":"create","record":{"id":"b"}}`)
+	conn.send("posts", `invalid`)
+	conn.send("posts", `{"action":"delete","record":{"id":"a"}}`)
+
+	expected := []string{"create a", "update a", "create b", "error", "delete a"}
+	for _, want := range expected {
+		select {
+		case got := <-calls:
+			assert.Equal(t, want, got)
+		case <-time.After(5 * time.Second):
+			t.Fatal("handler not called")
+		}
+	}
+}
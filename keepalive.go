@@ -0,0 +1,90 @@
+package pocketbase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var ErrIdleTimeout = errors.New("realtime connection idle timeout")
+
+// idleTimeoutReader closes the underlying body when nothing was read within the timeout,
+// which unblocks a pending read of a half-open connection.
+type idleTimeoutReader struct {
+	io.ReadCloser
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+func newIdleTimeoutReader(body io.ReadCloser, timeout time.Duration) *idleTimeoutReader {
+	r := &idleTimeoutReader{
+		ReadCloser: body,
+		timeout:    timeout,
+	}
+	r.timer = time.AfterFunc(timeout, func() {
+		r.timedOut.Store(true)
+		_ = r.ReadCloser.Close()
+	})
+	return r
+}
+
+func (r *idleTimeoutReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	if err != nil && r.timedOut.Load() {
+		err = ErrIdleTimeout
+	}
+	return n, err
+}
+
+func (r *idleTimeoutReader) Close() error {
+	r.timer.Stop()
+	return r.ReadCloser.Close()
+}
+
+// newKeepAliveClient returns a client for the realtime connection, which sends TCP keep-alive probes
+// in the given period. It shares the settings of the base client, and falls back to the base client
+// if its transport can't be configured.
+func newKeepAliveClient(base *resty.Client, period time.Duration) *resty.Client {
+	transport, ok := base.GetClient().Transport.(*http.Transport)
+	if !ok {
+		return base
+	}
+	transport = transport.Clone()
+
+	dial := transport.DialContext
+	// Dial is deprecated, but custom transports still use it
+	if dial == nil && transport.Dial != nil { //nolint:staticcheck
+		legacyDial := transport.Dial //nolint:staticcheck
+		dial = func(_ context.Context, network, addr string) (net.Conn, error) {
+			return legacyDial(network, addr)
+		}
+	}
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second}).DialContext
+	}
+	transport.Dial = nil //nolint:staticcheck
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if tcp, ok := conn.(*net.TCPConn); ok && err == nil {
+			_ = tcp.SetKeepAlive(true)
+			_ = tcp.SetKeepAlivePeriod(period)
+		}
+		return conn, err
+	}
+
+	return resty.NewWithClient(&http.Client{Transport: transport}).
+		SetRetryCount(base.RetryCount).
+		SetRetryWaitTime(base.RetryWaitTime).
+		SetRetryMaxWaitTime(base.RetryMaxWaitTime).
+		SetDebug(base.Debug)
+}
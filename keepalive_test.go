@@ -0,0 +1,81 @@
+package pocketbase
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream_IdleTimeout(t *testing.T) {
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.SubscribeWith(SubscribeOptions{IdleTimeout: 300 * time.Millisecond})
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+	states := stream.States()
+	assert.Equal(t, StateConnected, nextState(t, states).State)
+
+	t.Run("keep-alive messages keep the connection", func(t *testing.T) {
+		conn := server.conn(t)
+		for i := 0; i < 6; i++ {
+			conn.write(":ping\n\n")
+			time.Sleep(100 * time.Millisecond)
+		}
+		select {
+		case sc := <-states:
+			t.Fatalf("unexpected state change %v", sc)
+		default:
+		}
+	})
+
+	t.Run("idle connection is reconnected", func(t *testing.T) {
+		sc := nextState(t, states)
+		assert.Equal(t, StateDisconnected, sc.State)
+		assert.ErrorIs(t, sc.Err, ErrIdleTimeout)
+		assert.Equal(t, StateReconnecting, nextState(t, states).State)
+		assert.Equal(t, StateChange{State: StateConnected, ClientID: "client2"}, nextState(t, states))
+
+		conn := server.conn(t)
+		conn.send("posts", `{"action":"create","record":{"id":"a"}}`)
+		e := <-stream.Events()
+		assert.Equal(t, "a", e.Record["id"])
+	})
+}
+
+func TestStream_KeepAlive(t *testing.T) {
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+	client.client.SetHeader("Authorization", "token")
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.SubscribeWith(SubscribeOptions{KeepAlive: 10 * time.Second})
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+
+	server.conn(t).send("posts", `{"action":"create","record":{"id":"a"}}`)
+	e := <-stream.Events()
+	assert.Equal(t, "a", e.Record["id"])
+	assert.Equal(t, "token", server.connectHeader.Load().(http.Header).Get("Authorization"))
+}
+
+func TestNewKeepAliveClient(t *testing.T) {
+	base := NewClient(defaultURL).client
+
+	client := newKeepAliveClient(base, 10*time.Second)
+	assert.NotSame(t, base, client)
+	transport, ok := client.GetClient().Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.NotNil(t, transport.DialContext)
+	assert.Equal(t, base.RetryCount, client.RetryCount)
+
+	// custom round trippers can't be configured
+	base.SetTransport(&countingTransport{RoundTripper: http.DefaultTransport})
+	assert.Same(t, base, newKeepAliveClient(base, 10*time.Second))
+}
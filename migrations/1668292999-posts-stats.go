@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db)
+
+		if _, err := dao.FindCollectionByNameOrId(PostsStats); err == nil {
+			return nil
+		}
+
+		posts, err := dao.FindCollectionByNameOrId(PostsPublic)
+		if err != nil {
+			return err
+		}
+
+		log.Println("inserting collection: ", PostsStats)
+
+		collection := &models.Collection{
+			Name:       PostsStats,
+			Type:       models.CollectionTypeBase,
+			ListRule:   types.Pointer(""),
+			ViewRule:   types.Pointer(""),
+			CreateRule: types.Pointer(""),
+			UpdateRule: types.Pointer(""),
+			DeleteRule: types.Pointer(""),
+			Schema: schema.NewSchema(
+				&schema.SchemaField{
+					Name:    "views",
+					Type:    schema.FieldTypeNumber,
+					Options: &schema.NumberOptions{},
+				},
+				&schema.SchemaField{
+					Name: "tags",
+					Type: schema.FieldTypeSelect,
+					Options: &schema.SelectOptions{
+						MaxSelect: 3,
+						Values:    []string{"go", "web", "db"},
+					},
+				},
+				&schema.SchemaField{
+					Name: "posts",
+					Type: schema.FieldTypeRelation,
+					Options: &schema.RelationOptions{
+						CollectionId: posts.Id,
+					},
+				},
+			),
+		}
+
+		return dao.SaveCollection(collection)
+	}, func(_ dbx.Builder) error {
+		return nil
+	})
+}
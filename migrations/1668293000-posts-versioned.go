@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db)
+
+		if _, err := dao.FindCollectionByNameOrId(PostsVersioned); err == nil {
+			return nil
+		}
+
+		log.Println("inserting collection: ", PostsVersioned)
+
+		collection := &models.Collection{
+			Name:       PostsVersioned,
+			Type:       models.CollectionTypeBase,
+			ListRule:   types.Pointer(""),
+			ViewRule:   types.Pointer(""),
+			CreateRule: types.Pointer(""),
+			// updates must be based on the current version
+			UpdateRule: types.Pointer("@request.data.version > version"),
+			DeleteRule: types.Pointer(""),
+			Schema: schema.NewSchema(
+				&schema.SchemaField{
+					Name:    "field",
+					Type:    schema.FieldTypeText,
+					Options: &schema.TextOptions{},
+				},
+				&schema.SchemaField{
+					Name:    "version",
+					Type:    schema.FieldTypeNumber,
+					Options: &schema.NumberOptions{},
+				},
+			),
+		}
+
+		return dao.SaveCollection(collection)
+	}, func(_ dbx.Builder) error {
+		return nil
+	})
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"log"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(db dbx.Builder) error {
+		dao := daos.New(db)
+
+		if _, err := dao.FindCollectionByNameOrId(PostsUnique); err == nil {
+			return nil
+		}
+
+		log.Println("inserting collection: ", PostsUnique)
+
+		collection := &models.Collection{
+			Name:       PostsUnique,
+			Type:       models.CollectionTypeBase,
+			ListRule:   types.Pointer(""),
+			ViewRule:   types.Pointer(""),
+			CreateRule: types.Pointer(""),
+			UpdateRule: types.Pointer(""),
+			DeleteRule: types.Pointer(""),
+			Schema: schema.NewSchema(
+				&schema.SchemaField{
+					Name:    "field",
+					Type:    schema.FieldTypeText,
+					Options: &schema.TextOptions{},
+				},
+				&schema.SchemaField{
+					Name:    "slug",
+					Type:    schema.FieldTypeText,
+					Options: &schema.TextOptions{},
+				},
+			),
+			Indexes: types.JsonArray[string]{
+				"CREATE UNIQUE INDEX idx_posts_unique_slug ON " + PostsUnique + " (slug)",
+			},
+		}
+
+		return dao.SaveCollection(collection)
+	}, func(_ dbx.Builder) error {
+		return nil
+	})
+}
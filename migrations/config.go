@@ -5,6 +5,9 @@ const (
 	PostsAdmin         = "posts_admin"
 	PostsUser          = "posts_user"
 	PostsPublic        = "posts_public"
+	PostsStats         = "posts_stats"
+	PostsVersioned     = "posts_versioned"
+	PostsUnique        = "posts_unique"
 	AdminEmailPassword = "admin@admin.com"
 	UserEmailPassword  = "user@user.com" //nolint:gosec
 )
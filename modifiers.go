@@ -0,0 +1,69 @@
+package pocketbase
+
+import "maps"
+
+// number is a value of a number field.
+type number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+// Increment adds n to a number field on the server, to the value it has when the update is applied,
+// instead of a value read before by the client. Note that PocketBase v0.22 applies modifiers to the record
+// loaded by the request, so concurrent updates of the same record can still be lost.
+//
+// Example:
+//
+//	post, err := posts.UpdateFields(id, pocketbase.Increment("views", 1))
+func Increment[N number](field string, n N) Patch {
+	return Patch{field + "+": n}
+}
+
+// Decrement subtracts n from a number field on the server.
+func Decrement[N number](field string, n N) Patch {
+	return Patch{field + "-": n}
+}
+
+// AppendRelation adds the record ids at the end of a multiple relation field.
+//
+// Example:
+//
+//	post, err := posts.UpdateFields(id, pocketbase.AppendRelation("tags", tagID).With(pocketbase.Increment("version", 1)))
+func AppendRelation(field string, ids ...string) Patch {
+	return Patch{field + "+": ids}
+}
+
+// RemoveRelation removes the record ids from a relation field.
+func RemoveRelation(field string, ids ...string) Patch {
+	return Patch{field + "-": ids}
+}
+
+// AppendSelect adds the values at the end of a multiple select field.
+func AppendSelect(field string, values ...string) Patch {
+	return Patch{field + "+": values}
+}
+
+// RemoveSelect removes the values from a select field.
+func RemoveSelect(field string, values ...string) Patch {
+	return Patch{field + "-": values}
+}
+
+// RemoveFiles deletes the files by name from a file field. New files can be uploaded with multipart requests only.
+func RemoveFiles(field string, names ...string) Patch {
+	return Patch{field + "-": names}
+}
+
+// With returns a copy of the patch with the fields of others, so fields and modifiers are sent in one update.
+//
+// Example:
+//
+//	patch := pocketbase.Patch{"title": "new title"}.With(pocketbase.Increment("revision", 1))
+func (p Patch) With(others ...Patch) Patch {
+	merged := maps.Clone(p)
+	if merged == nil {
+		merged = Patch{}
+	}
+	for _, other := range others {
+		maps.Copy(merged, other)
+	}
+	return merged
+}
@@ -0,0 +1,106 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zcharym/pocketbase-client/migrations"
+)
+
+func TestModifiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch Patch
+		want  string
+	}{
+		{"Increment", Increment("views", 2), `{"views+":2}`},
+		{"Increment float", Increment("score", 0.5), `{"score+":0.5}`},
+		{"Decrement", Decrement("views", int64(1)), `{"views-":1}`},
+		{"AppendRelation", AppendRelation("posts", "p1", "p2"), `{"posts+":["p1","p2"]}`},
+		{"RemoveRelation", RemoveRelation("posts", "p1"), `{"posts-":["p1"]}`},
+		{"AppendSelect", AppendSelect("tags", "go"), `{"tags+":["go"]}`},
+		{"RemoveSelect", RemoveSelect("tags", "db"), `{"tags-":["db"]}`},
+		{"RemoveFiles", RemoveFiles("avatar", "a.png"), `{"avatar-":["a.png"]}`},
+		{
+			"With",
+			Patch{"title": "new"}.With(Increment("views", 1), RemoveSelect("tags", "db")),
+			`{"title":"new","views+":1,"tags-":["db"]}`,
+		},
+		{"With on nil", Patch(nil).With(Increment("views", 1)), `{"views+":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.patch)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(data))
+		})
+	}
+
+	t.Run("With copies", func(t *testing.T) {
+		patch := Patch{"title": "new"}
+		_ = patch.With(Increment("views", 1))
+		assert.Equal(t, Patch{"title": "new"}, patch)
+	})
+}
+
+func TestCollection_UpdateFieldsModifiers(t *testing.T) {
+	type stats struct {
+		BaseRecord
+		Views int      `json:"views"`
+		Tags  []string `json:"tags"`
+		Posts []string `json:"posts"`
+	}
+	client := NewClient(defaultURL)
+	posts := CollectionSet[map[string]any](client, migrations.PostsPublic)
+	collection := CollectionSet[stats](client, migrations.PostsStats)
+
+	post1, err := posts.Create(map[string]any{"field": "modifiers_1"})
+	require.NoError(t, err)
+	post2, err := posts.Create(map[string]any{"field": "modifiers_2"})
+	require.NoError(t, err)
+	created, err := collection.CreateWithParams(stats{Tags: []string{"web"}}, ParamsList{})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, collection.Delete(created.ID))
+		assert.NoError(t, posts.Delete(post1.ID))
+		assert.NoError(t, posts.Delete(post2.ID))
+	}()
+
+	t.Run("numbers", func(t *testing.T) {
+		for range 10 {
+			_, err := collection.UpdateFields(created.ID, Increment("views", 1))
+			require.NoError(t, err)
+		}
+
+		updated, err := collection.UpdateFields(created.ID, Decrement("views", 3))
+		require.NoError(t, err)
+		assert.Equal(t, 7, updated.Views)
+	})
+
+	t.Run("selects", func(t *testing.T) {
+		updated, err := collection.UpdateFields(created.ID, AppendSelect("tags", "go", "db"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"web", "go", "db"}, updated.Tags)
+
+		updated, err = collection.UpdateFields(created.ID, RemoveSelect("tags", "web"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "db"}, updated.Tags)
+	})
+
+	t.Run("relations", func(t *testing.T) {
+		updated, err := collection.UpdateFields(created.ID, AppendRelation("posts", post1.ID))
+		require.NoError(t, err)
+		assert.Equal(t, []string{post1.ID}, updated.Posts)
+
+		updated, err = collection.UpdateFields(created.ID, AppendRelation("posts", post2.ID).With(Patch{"views": 0}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{post1.ID, post2.ID}, updated.Posts)
+		assert.Zero(t, updated.Views)
+
+		updated, err = collection.UpdateFields(created.ID, RemoveRelation("posts", post1.ID))
+		require.NoError(t, err)
+		assert.Equal(t, []string{post2.ID}, updated.Posts)
+	})
+}
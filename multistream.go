@@ -0,0 +1,171 @@
+package pocketbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/SierraSoftworks/multicast/v2"
+)
+
+// Decoder decodes the raw record of an AnyEvent, see DecoderOf.
+type Decoder func(raw json.RawMessage) (any, error)
+
+// DecoderOf returns a Decoder which decodes records into T.
+func DecoderOf[T any]() Decoder {
+	return func(raw json.RawMessage) (any, error) {
+		var record T
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+}
+
+// AnyEvent is a realtime event of any collection, see Client.SubscribeMany.
+type AnyEvent struct {
+	// Collection is the name of the record collection, taken from the collectionName field.
+	Collection string
+	Action     string
+	Raw        json.RawMessage
+	// Record is the record decoded by the Decoder of the collection, nil without a decoder.
+	Record any
+	Error  error
+}
+
+// As decodes the record of the event into T. The record already decoded into T by a Decoder is reused.
+//
+// Example:
+//
+//	switch ev.Collection {
+//	case "posts":
+//		post, err := pocketbase.As[Post](ev)
+//	case "comments":
+//		comment, err := pocketbase.As[Comment](ev)
+//	}
+func As[T any](ev AnyEvent) (T, error) {
+	if record, ok := ev.Record.(T); ok {
+		return record, nil
+	}
+	var record T
+	if ev.Error != nil {
+		return record, ev.Error
+	}
+	if err := json.Unmarshal(ev.Raw, &record); err != nil {
+		return record, fmt.Errorf("[realtime] can't decode %s record, err %w", ev.Collection, err)
+	}
+	return record, nil
+}
+
+// MultiStream delivers the events of several collections in the order they were received.
+type MultiStream struct {
+	stream     *Stream[json.RawMessage]
+	decoders   map[string]Decoder
+	channel    *multicast.Channel[AnyEvent]
+	onceListen *sync.Once
+}
+
+// SubscribeMany subscribes to the topics given as the keys of the decoders map
+// (e.g. "posts" or "posts/RECORD_ID") over the shared realtime connection. Records of collections
+// with a non-nil Decoder are decoded into AnyEvent.Record. The stream is unsubscribed when ctx is done.
+//
+// Example:
+//
+//	stream, err := client.SubscribeMany(ctx, map[string]pocketbase.Decoder{
+//		"posts":    pocketbase.DecoderOf[Post](),
+//		"comments": nil, // decode later with pocketbase.As
+//	})
+func (c *Client) SubscribeMany(ctx context.Context, decoders map[string]Decoder) (*MultiStream, error) {
+	topics := make([]string, 0, len(decoders))
+	byCollection := make(map[string]Decoder, len(decoders))
+	for topic, decoder := range decoders {
+		topics = append(topics, topic)
+		if decoder != nil {
+			collection, _ := splitTopic(topic)
+			byCollection[collection] = decoder
+		}
+	}
+	sort.Strings(topics)
+
+	stream, err := Subscribe[json.RawMessage](c.Realtime(), topics...)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Unsubscribe()
+		case <-stream.done:
+		}
+	}()
+
+	return &MultiStream{
+		stream:     stream,
+		decoders:   byCollection,
+		channel:    multicast.New[AnyEvent](),
+		onceListen: &sync.Once{},
+	}, nil
+}
+
+func (m *MultiStream) deliver(events <-chan Event[json.RawMessage]) {
+	defer m.channel.Close()
+	for e := range events {
+		m.channel.C <- m.convert(e)
+	}
+}
+
+func (m *MultiStream) convert(e Event[json.RawMessage]) AnyEvent {
+	ev := AnyEvent{Action: e.Action, Raw: e.Record, Error: e.Error}
+	if ev.Error != nil || len(ev.Raw) == 0 {
+		return ev
+	}
+
+	var record struct {
+		CollectionName string `json:"collectionName"`
+	}
+	if err := json.Unmarshal(ev.Raw, &record); err != nil {
+		ev.Error = err
+		return ev
+	}
+	ev.Collection = record.CollectionName
+
+	if decoder := m.decoders[ev.Collection]; decoder != nil {
+		ev.Record, ev.Error = decoder(ev.Raw)
+	}
+	return ev
+}
+
+// Events returns the channel of events, which is closed once the stream is terminated.
+func (m *MultiStream) Events() <-chan AnyEvent {
+	l := m.channel.Listen()
+	m.onceListen.Do(func() {
+		go m.deliver(m.stream.Events())
+	})
+	return l.C
+}
+
+// States works like Stream.States.
+func (m *MultiStream) States() <-chan StateChange {
+	return m.stream.States()
+}
+
+// Err works like Stream.Err.
+func (m *MultiStream) Err() error {
+	return m.stream.Err()
+}
+
+func (m *MultiStream) Dropped() uint64 {
+	return m.stream.Dropped()
+}
+
+// Ready works like Stream.Ready.
+func (m *MultiStream) Ready() <-chan struct{} {
+	return m.stream.Ready()
+}
+
+func (m *MultiStream) Unsubscribe() {
+	m.stream.Unsubscribe()
+}
@@ -0,0 +1,77 @@
+package pocketbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SubscribeMany(t *testing.T) {
+	type post struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	}
+	type comment struct {
+		ID   string `json:"id"`
+		Text string `json:"text"`
+	}
+
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.SubscribeMany(ctx, map[string]Decoder{
+		"posts":    DecoderOf[post](),
+		"comments": nil,
+	})
+	require.NoError(t, err)
+	<-stream.Ready()
+	assert.Equal(t, []string{"comments", "posts"}, client.Realtime().Topics())
+
+	conn := server.conn(t)
+	go func() {
+		for i := 0; i < 100; i++ {
+			conn.send("posts", fmt.Sprintf(`{"action":"create","record":{"collectionName":"posts","id":"p%d","title":"t%d"}}`, i, i))
+			conn.send("comments", fmt.Sprintf(`{"action":"update","record":{"collectionName":"comments","id":"c%d","text":"t%d"}}`, i, i))
+		}
+	}()
+
+	ch := stream.Events()
+	for i := 0; i < 100; i++ {
+		ev := <-ch
+		require.NoError(t, ev.Error)
+		assert.Equal(t, "posts", ev.Collection)
+		assert.Equal(t, ActionCreate, ev.Action)
+		assert.Equal(t, post{ID: fmt.Sprintf("p%d", i), Title: fmt.Sprintf("t%d", i)}, ev.Record)
+		p, err := As[post](ev)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("p%d", i), p.ID)
+
+		ev = <-ch
+		require.NoError(t, ev.Error)
+		assert.Equal(t, "comments", ev.Collection)
+		assert.Equal(t, ActionUpdate, ev.Action)
+		assert.Nil(t, ev.Record)
+		c, err := As[comment](ev)
+		require.NoError(t, err)
+		assert.Equal(t, comment{ID: fmt.Sprintf("c%d", i), Text: fmt.Sprintf("t%d", i)}, c)
+	}
+
+	cancel()
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Empty(t, client.Realtime().Topics())
+}
+
+func TestAs(t *testing.T) {
+	_, err := As[map[string]any](AnyEvent{Collection: "posts", Raw: json.RawMessage(`[]`)})
+	assert.Error(t, err)
+
+	_, err = As[map[string]any](AnyEvent{Error: ErrInvalidResponse})
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+}
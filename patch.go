@@ -0,0 +1,213 @@
+package pocketbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Optional is a field with explicit presence. Diff, Collection.Patch and Collection.UpdateFields
+// leave it out unless Set is true, a set zero value clears the field on purpose.
+//
+// Example:
+//
+//	type PostUpdate struct {
+//		Title  pocketbase.Optional[string] `json:"title"`
+//		Author pocketbase.Optional[string] `json:"author"`
+//	}
+//
+//	// sends {"author":""}, which clears the relation and keeps the title
+//	post, err := posts.UpdateFields(id, PostUpdate{Author: pocketbase.Some("")})
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+// Some returns a set Optional.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Value: value, Set: true}
+}
+
+// Get returns the value and whether it's set.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set
+}
+
+// MarshalJSON encodes an unset Optional as null.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON sets the Optional, null included.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var value T
+	if !bytes.Equal(data, []byte("null")) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+	o.Value, o.Set = value, true
+	return nil
+}
+
+func (o Optional[T]) isSet() bool {
+	return o.Set
+}
+
+// optional is implemented by Optional to report the presence of a field.
+type optional interface {
+	isSet() bool
+}
+
+// Patch is the body of a partial update, keyed by field name.
+type Patch map[string]any
+
+// serverFields are managed by PocketBase and never sent by Diff.
+var serverFields = map[string]bool{
+	"id":             true,
+	"collectionId":   true,
+	"collectionName": true,
+	"created":        true,
+	"updated":        true,
+	"expand":         true,
+}
+
+// Diff returns the fields of modified which differ from original, compared by their JSON values.
+// A changed field is sent even if it's the zero value and tagged with omitempty, so it's cleared.
+// Unset Optional fields, the fields of BaseRecord, "expand" and the fields tagged with json:"-"
+// are skipped. Maps are compared by key, a removed key is sent as null.
+//
+// Example:
+//
+//	original, _ := posts.One(id)
+//	modified := original
+//	modified.Title = ""
+//	patch, err := pocketbase.Diff(original, modified) // {"title":""}
+func Diff[T any](original, modified T) (Patch, error) {
+	typ := indirect(reflect.TypeOf((*T)(nil)).Elem())
+	if typ.Kind() == reflect.Struct {
+		return diffStruct(reflect.ValueOf(original), reflect.ValueOf(modified))
+	}
+	return diffJSON(original, modified)
+}
+
+func diffStruct(original, modified reflect.Value) (Patch, error) {
+	original, modified = indirectValue(original), indirectValue(modified)
+	if !modified.IsValid() {
+		return Patch{}, nil
+	}
+	typ := modified.Type()
+
+	patch := Patch{}
+	for _, f := range reflect.VisibleFields(typ) {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || f.Anonymous || name == "-" {
+			continue
+		}
+		if len(f.Index) > 1 && !promoted(typ, f.Index) {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if serverFields[name] {
+			continue
+		}
+
+		value, ok := fieldByIndex(modified, f.Index)
+		if !ok {
+			continue
+		}
+		if opt, ok := value.Interface().(optional); ok && !opt.isSet() {
+			continue
+		}
+
+		newData, err := json.Marshal(value.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("[diff] can't marshal %s, err %w", name, err)
+		}
+		if original.IsValid() {
+			if old, ok := fieldByIndex(original, f.Index); ok {
+				oldData, err := json.Marshal(old.Interface())
+				if err != nil {
+					return nil, fmt.Errorf("[diff] can't marshal %s, err %w", name, err)
+				}
+				if bytes.Equal(oldData, newData) {
+					continue
+				}
+			}
+		}
+		patch[name] = json.RawMessage(newData)
+	}
+	return patch, nil
+}
+
+func diffJSON(original, modified any) (Patch, error) {
+	var oldFields, newFields map[string]json.RawMessage
+	if err := remarshal(original, &oldFields); err != nil {
+		return nil, fmt.Errorf("[diff] can't marshal original, err %w", err)
+	}
+	if err := remarshal(modified, &newFields); err != nil {
+		return nil, fmt.Errorf("[diff] can't marshal modified, err %w", err)
+	}
+
+	patch := Patch{}
+	for name, value := range newFields {
+		if serverFields[name] {
+			continue
+		}
+		if old, ok := oldFields[name]; !ok || !jsonEqual(old, value) {
+			patch[name] = value
+		}
+	}
+	for name := range oldFields {
+		if _, ok := newFields[name]; !ok && !serverFields[name] {
+			patch[name] = nil
+		}
+	}
+	return patch, nil
+}
+
+func remarshal(from any, into any) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, which returns false instead of panicking on a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			if v = indirectValue(v); !v.IsValid() {
+				return v, false
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,150 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type patchPost struct {
+	BaseRecord
+	Title  string          `json:"title"`
+	Draft  bool            `json:"draft,omitempty"`
+	Tags   []string        `json:"tags"`
+	Score  Optional[int]   `json:"score"`
+	Author *expandUser     `json:"-" pb:"expand=author"`
+	Expand json.RawMessage `json:"expand,omitempty"`
+}
+
+func TestDiff(t *testing.T) {
+	original := patchPost{
+		BaseRecord: BaseRecord{ID: "p1"},
+		Title:      "hello",
+		Draft:      true,
+		Tags:       []string{"t1"},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *patchPost)
+		want   string
+	}{
+		{
+			name:   "unchanged",
+			modify: func(p *patchPost) { p.Author = &expandUser{ID: "u1"} },
+			want:   `{}`,
+		},
+		{
+			name:   "changed to the zero value",
+			modify: func(p *patchPost) { p.Draft = false; p.Tags = nil },
+			want:   `{"draft":false,"tags":null}`,
+		},
+		{
+			name: "server fields",
+			modify: func(p *patchPost) {
+				p.ID = "p2"
+				p.Updated = NewDateTime(p.Updated.Add(1))
+				p.Expand = json.RawMessage(`{}`)
+			},
+			want: `{}`,
+		},
+		{
+			name:   "set optional",
+			modify: func(p *patchPost) { p.Score = Some(0) },
+			want:   `{"score":0}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := original
+			tt.modify(&modified)
+			patch, err := Diff(original, modified)
+			require.NoError(t, err)
+			data, err := json.Marshal(patch)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(data))
+		})
+	}
+
+	t.Run("maps", func(t *testing.T) {
+		patch, err := Diff(
+			map[string]any{"id": "p1", "title": "hello", "views": 1, "tags": []string{"t1"}},
+			map[string]any{"id": "p1", "title": "hello", "views": 2.0, "draft": true},
+		)
+		require.NoError(t, err)
+		data, err := json.Marshal(patch)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"views":2,"draft":true,"tags":null}`, string(data))
+	})
+}
+
+func TestOptional(t *testing.T) {
+	var v struct {
+		Title Optional[string] `json:"title"`
+		Score Optional[int]    `json:"score"`
+		Tags  Optional[[]int]  `json:"tags"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"title":"hello","tags":null}`), &v))
+	assert.Equal(t, Some("hello"), v.Title)
+	assert.False(t, v.Score.Set)
+	tags, ok := v.Tags.Get()
+	assert.True(t, ok)
+	assert.Nil(t, tags)
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"hello","score":null,"tags":null}`, string(data))
+}
+
+func TestCollection_Patch(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/api/collections/posts/records/p1", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		_, _ = w.Write([]byte(`{"id":"p1","title":"server","draft":false,"tags":[]}`))
+	}))
+	defer server.Close()
+
+	posts := CollectionSet[patchPost](NewClient(server.URL), "posts")
+	original := patchPost{BaseRecord: BaseRecord{ID: "p1"}, Title: "hello", Draft: true}
+
+	modified := original
+	modified.Draft = false
+	updated, err := posts.Patch("p1", original, modified)
+	require.NoError(t, err)
+	assert.Equal(t, "server", updated.Title)
+
+	unchanged, err := posts.Patch("p1", original, original)
+	require.NoError(t, err)
+	assert.Equal(t, original, unchanged)
+
+	_, err = posts.UpdateFields("p1", Patch{"title": "new"})
+	require.NoError(t, err)
+	_, err = posts.UpdateFields("p1", &struct {
+		Title Optional[string] `json:"title"`
+		Draft Optional[bool]   `json:"draft"`
+	}{Draft: Some(false)})
+	require.NoError(t, err)
+
+	require.Len(t, bodies, 3)
+	assert.JSONEq(t, `{"draft":false}`, bodies[0])
+	assert.JSONEq(t, `{"title":"new"}`, bodies[1])
+	assert.JSONEq(t, `{"draft":false}`, bodies[2])
+
+	t.Run("error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		_, err := CollectionSet[patchPost](NewClient(server.URL), "posts").UpdateFields("p1", Patch{"title": ""})
+		assert.ErrorIs(t, err, ErrInvalidResponse)
+	})
+}
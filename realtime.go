@@ -0,0 +1,245 @@
+package pocketbase
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var ErrNoTopics = errors.New("no topics to subscribe")
+
+type realtimeSubscriber interface {
+	dispatch(ev sseEvent)
+	markReady()
+	changeState(sc StateChange)
+	topicError(topic string, err error)
+}
+
+// Realtime multiplexes all shared realtime subscriptions of a client over a single
+// SSE connection. The connection is opened with the first subscription and closed
+// once the last stream is unsubscribed.
+type Realtime struct {
+	client *Client
+	opts   SubscribeOptions
+
+	// connMu serializes connection changes, mu guards the topics for event dispatching.
+	connMu sync.Mutex
+	conn   *realtimeConn
+	mu     sync.RWMutex
+	topics map[string]map[realtimeSubscriber]struct{}
+}
+
+func newRealtime(client *Client, opts SubscribeOptions) *Realtime {
+	return &Realtime{
+		client: client,
+		opts:   opts,
+		topics: map[string]map[realtimeSubscriber]struct{}{},
+	}
+}
+
+// Subscribe subscribes to the given topics (e.g. "posts" or "posts/RECORD_ID")
+// over the shared realtime connection of the client.
+//
+// Example:
+//
+//	stream, err := pocketbase.Subscribe[Post](client.Realtime(), "posts", "comments")
+func Subscribe[T any](r *Realtime, topics ...string) (*Stream[T], error) {
+	return SubscribeWith[T](r, r.opts, topics...)
+}
+
+// SubscribeWith works like Subscribe with custom stream options.
+// Only BufferSize, OverflowPolicy, CatchUp and Recorder are used, the connection itself
+// is configured with the WithRealtimeOptions client option.
+func SubscribeWith[T any](r *Realtime, opts SubscribeOptions, topics ...string) (*Stream[T], error) {
+	if len(topics) == 0 {
+		return nil, ErrNoTopics
+	}
+	if err := r.client.Authorize(); err != nil {
+		return nil, err
+	}
+
+	stream := newStream[T](r.client, opts, topics)
+	stream.unsubscribe = func() { r.remove(stream, topics) }
+	if err := r.add(stream, topics); err != nil {
+		stream.discard()
+		return nil, err
+	}
+	return stream, nil
+}
+
+// SubscribeShared works like Subscribe, but uses the shared realtime connection
+// of the client instead of opening a new one.
+func (c *Collection[T]) SubscribeShared(targets ...string) (*Stream[T], error) {
+	if len(targets) == 0 {
+		targets = []string{c.Name}
+	}
+	return Subscribe[T](c.Realtime(), targets...)
+}
+
+// SubscribeSharedWith works like SubscribeShared with custom stream options.
+func (c *Collection[T]) SubscribeSharedWith(opts SubscribeOptions, targets ...string) (*Stream[T], error) {
+	if len(targets) == 0 {
+		targets = []string{c.Name}
+	}
+	return SubscribeWith[T](c.Realtime(), opts, targets...)
+}
+
+// Topics returns the union of the currently subscribed topics.
+func (r *Realtime) Topics() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	topics := make([]string, 0, len(r.topics))
+	for topic := range r.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+func (r *Realtime) add(sub realtimeSubscriber, topics []string) error {
+	r.connMu.Lock()
+	defer r.connMu.Unlock()
+
+	r.register(sub, topics)
+
+	if r.conn == nil {
+		shared := &sharedConn{Realtime: r}
+		shared.conn = newRealtimeConn(r.client, r.opts, r.Topics, shared)
+		if err := shared.conn.start(); err != nil {
+			r.unregister(sub, topics)
+			return err
+		}
+		r.conn = shared.conn
+		return nil
+	}
+
+	if err := r.conn.subscribe(); err != nil {
+		r.unregister(sub, topics)
+		return err
+	}
+	if clientID := r.conn.currentClientID(); clientID != "" {
+		sub.changeState(StateChange{State: StateConnected, ClientID: clientID})
+	}
+	return nil
+}
+
+func (r *Realtime) remove(sub realtimeSubscriber, topics []string) {
+	r.connMu.Lock()
+	defer r.connMu.Unlock()
+
+	if empty := r.unregister(sub, topics); empty {
+		if r.conn != nil {
+			r.conn.close()
+			r.conn = nil
+		}
+		return
+	}
+
+	if r.conn != nil {
+		if err := r.conn.subscribe(); err != nil {
+			r.client.logger.Printf("[realtime] can't update subscriptions, err %v", err)
+		}
+	}
+}
+
+func (r *Realtime) register(sub realtimeSubscriber, topics []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, topic := range topics {
+		if r.topics[topic] == nil {
+			r.topics[topic] = map[realtimeSubscriber]struct{}{}
+		}
+		r.topics[topic][sub] = struct{}{}
+	}
+}
+
+func (r *Realtime) unregister(sub realtimeSubscriber, topics []string) (empty bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, topic := range topics {
+		delete(r.topics[topic], sub)
+		if len(r.topics[topic]) == 0 {
+			delete(r.topics, topic)
+		}
+	}
+	return len(r.topics) == 0
+}
+
+func (r *Realtime) subscribers() map[realtimeSubscriber]struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subs := map[realtimeSubscriber]struct{}{}
+	for _, topicSubs := range r.topics {
+		for sub := range topicSubs {
+			subs[sub] = struct{}{}
+		}
+	}
+	return subs
+}
+
+// sharedConn passes the callbacks of a connection to the subscribers of the shared realtime.
+type sharedConn struct {
+	*Realtime
+	conn *realtimeConn
+}
+
+// dispatch pushes the event without holding the topics lock, which blocking streams would stall.
+func (s *sharedConn) dispatch(ev sseEvent) {
+	for _, sub := range s.topicSubscribers(ev.Event) {
+		sub.dispatch(ev)
+	}
+}
+
+// topicError pushes the error without holding the topics lock, which blocking streams would stall.
+func (s *sharedConn) topicError(topic string, err error) {
+	for _, sub := range s.topicSubscribers(topic) {
+		sub.topicError(topic, err)
+	}
+}
+
+func (s *sharedConn) topicSubscribers(topic string) []realtimeSubscriber {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subs := make([]realtimeSubscriber, 0, len(s.topics[topic]))
+	for sub := range s.topics[topic] {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+func (s *sharedConn) markReady() {
+	for sub := range s.subscribers() {
+		sub.markReady()
+	}
+}
+
+func (s *sharedConn) changeState(sc StateChange) {
+	if sc.State != StateClosed {
+		for sub := range s.subscribers() {
+			sub.changeState(sc)
+		}
+		return
+	}
+
+	// the connection gave up, so all its streams are terminated
+	s.connMu.Lock()
+	if s.Realtime.conn != s.conn {
+		s.connMu.Unlock()
+		return
+	}
+	s.Realtime.conn = nil
+	subs := s.subscribers()
+	s.mu.Lock()
+	s.topics = map[string]map[realtimeSubscriber]struct{}{}
+	s.mu.Unlock()
+	s.connMu.Unlock()
+
+	for sub := range subs {
+		sub.changeState(sc)
+	}
+}
@@ -0,0 +1,131 @@
+package pocketbase
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+var (
+	ErrAuthChanged    = errors.New("realtime authorization changed")
+	ErrTopicForbidden = errors.New("realtime topic is not authorized")
+)
+
+// authWatchers notifies the realtime connections of a client about token changes,
+// so the subscriptions are posted again with the new token.
+type authWatchers struct {
+	mu    sync.Mutex
+	token string
+	conns map[*realtimeConn]struct{}
+}
+
+func (c *Client) watchAuth(conn *realtimeConn) {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+	if c.auth.conns == nil {
+		c.auth.conns = map[*realtimeConn]struct{}{}
+	}
+	c.auth.conns[conn] = struct{}{}
+}
+
+func (c *Client) unwatchAuth(conn *realtimeConn) {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+	delete(c.auth.conns, conn)
+}
+
+// checkAuthChange signals the realtime connections to re-authorize, when the token of the auth store
+// differs from the previous call. The subscriptions are posted by the connections, not by the caller.
+func (c *Client) checkAuthChange() {
+	c.auth.mu.Lock()
+	defer c.auth.mu.Unlock()
+
+	token := c.authorizer.Token()
+	if token == c.auth.token {
+		return
+	}
+	c.auth.token = token
+	for conn := range c.auth.conns {
+		select {
+		case conn.authChanged <- struct{}{}:
+		default:
+			// a pending re-authorization reads the latest token anyway
+		}
+	}
+}
+
+// watchAuthChanges re-authorizes the connection after token changes until it's closed.
+func (r *realtimeConn) watchAuthChanges() {
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-r.authChanged:
+			r.reauthorize()
+		}
+	}
+}
+
+// reauthorize posts the subscriptions with the current token. PocketBase rejects another identity
+// for an existing client id, so the connection is restarted then and subscribes on reconnect.
+// Afterwards the topics are checked, because PocketBase accepts subscriptions to any topic
+// and silently skips the events which aren't visible to the new identity.
+func (r *realtimeConn) reauthorize() {
+	if err := r.subscribe(); err != nil {
+		r.client.logger.Printf("[realtime] reconnecting after auth change, err %v", err)
+		r.restart(ErrAuthChanged)
+	}
+	r.checkTopics()
+}
+
+// checkTopics reports the topics which can't be checked as error events, ErrTopicForbidden
+// for the topics which aren't visible with the current token.
+func (r *realtimeConn) checkTopics() {
+	for _, topic := range r.topics() {
+		if r.ctx.Err() != nil {
+			return
+		}
+		if err := r.client.checkTopic(topic); err != nil {
+			r.subscriber.topicError(topic, err)
+		}
+	}
+}
+
+// checkTopic checks the access to the records of a topic, the list API for collection topics
+// and the view API for record topics.
+func (c *Client) checkTopic(topic string) error {
+	collection, id := splitTopic(topic)
+
+	request := c.client.R().SetPathParam("collection", collection)
+	path := c.url + "/api/collections/{collection}/records"
+	if id != "" && id != "*" {
+		request.SetPathParam("id", id)
+		path += "/{id}"
+	} else {
+		request.SetQueryParams(map[string]string{"perPage": "1", "skipTotal": "1"})
+	}
+
+	resp, err := request.Get(path)
+	if err != nil {
+		return fmt.Errorf("[realtime] can't send request to pocketbase, err %w", err)
+	}
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden, http.StatusNotFound:
+		return fmt.Errorf("[realtime] %s: pocketbase returned status: %d, msg: %s, err %w",
+			topic,
+			resp.StatusCode(),
+			resp.String(),
+			ErrTopicForbidden,
+		)
+	default:
+		return fmt.Errorf("[realtime] %s: pocketbase returned status: %d, msg: %s, err %w",
+			topic,
+			resp.StatusCode(),
+			resp.String(),
+			ErrInvalidResponse,
+		)
+	}
+}
@@ -0,0 +1,127 @@
+package pocketbase
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuthStore sets the header only when the token changed, like the token refresh.
+type testAuthStore struct {
+	client  *resty.Client
+	mu      sync.Mutex
+	token   string
+	applied string
+}
+
+func (a *testAuthStore) authorize() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != a.applied {
+		a.client.SetHeader("Authorization", a.token)
+		a.applied = a.token
+	}
+	return nil
+}
+
+func (a *testAuthStore) IsValid() bool {
+	return true
+}
+
+func (a *testAuthStore) Token() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.applied
+}
+
+func (a *testAuthStore) setToken(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = token
+}
+
+func TestStream_Reauthorize(t *testing.T) {
+	server := newFakeRealtime(t)
+	var checks atomic.Int32
+	server.records = func(w http.ResponseWriter, r *http.Request) {
+		defer checks.Add(1)
+		switch identityOf(r.Header.Get("Authorization")) {
+		case "admin":
+		case "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Only admins can perform this action."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"page":1,"perPage":1,"items":[]}`))
+	}
+	client := NewClient(server.URL)
+	auth := &testAuthStore{client: client.client, token: "admin:1"}
+	client.authorizer = auth
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.Subscribe()
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+	states := stream.States()
+	events := stream.Events()
+	assert.Equal(t, StateChange{State: StateConnected, ClientID: "client1"}, nextState(t, states))
+	server.conn(t)
+
+	authOf := func(clientID string) string {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return server.auths[clientID]
+	}
+	assert.Equal(t, "admin:1", authOf("client1"))
+
+	t.Run("refreshed token is posted", func(t *testing.T) {
+		auth.setToken("admin:2")
+		require.NoError(t, client.Authorize())
+		// posted by the connection, not by the Authorize call
+		assert.Eventually(t, func() bool { return authOf("client1") == "admin:2" && checks.Load() == 1 }, 5*time.Second, time.Millisecond)
+		assert.Equal(t, int32(1), server.connects.Load())
+	})
+
+	t.Run("another identity reconnects", func(t *testing.T) {
+		auth.setToken("user:1")
+		require.NoError(t, client.Authorize())
+
+		sc := nextState(t, states)
+		assert.Equal(t, StateDisconnected, sc.State)
+		assert.ErrorIs(t, sc.Err, ErrAuthChanged)
+		assert.Equal(t, StateChange{State: StateReconnecting, Attempt: 1}, nextState(t, states))
+		assert.Equal(t, StateChange{State: StateConnected, ClientID: "client2"}, nextState(t, states))
+		server.conn(t)
+		assert.Equal(t, "user:1", authOf("client2"))
+
+		select {
+		case e := <-events:
+			assert.ErrorIs(t, e.Error, ErrTopicForbidden)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no topic error")
+		}
+	})
+
+	t.Run("failed topic checks are reported", func(t *testing.T) {
+		auth.setToken("unavailable:1")
+		require.NoError(t, client.Authorize())
+
+		select {
+		case e := <-events:
+			assert.ErrorIs(t, e.Error, ErrInvalidResponse)
+			assert.NotErrorIs(t, e.Error, ErrTopicForbidden)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no topic error")
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package pocketbase
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zcharym/pocketbase-client/migrations"
+)
+
+type countingTransport struct {
+	http.RoundTripper
+	connects atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet && req.URL.Path == "/api/realtime" {
+		t.connects.Add(1)
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+func TestRealtime_Subscribe(t *testing.T) {
+	client := NewClient(defaultURL, WithAdminEmailPassword(migrations.AdminEmailPassword, migrations.AdminEmailPassword))
+	transport := &countingTransport{RoundTripper: http.DefaultTransport}
+	client.client.SetTransport(transport)
+
+	public := CollectionSet[map[string]any](client, migrations.PostsPublic)
+	admin := CollectionSet[map[string]any](client, migrations.PostsAdmin)
+
+	publicStream, err := public.SubscribeShared()
+	require.NoError(t, err)
+	defer publicStream.Unsubscribe()
+	adminStream, err := Subscribe[map[string]any](client.Realtime(), migrations.PostsAdmin)
+	require.NoError(t, err)
+	defer adminStream.Unsubscribe()
+	<-publicStream.Ready()
+	<-adminStream.Ready()
+
+	assert.Equal(t, int32(1), transport.connects.Load())
+	assert.Equal(t, []string{migrations.PostsAdmin, migrations.PostsPublic}, client.Realtime().Topics())
+
+	publicCh := publicStream.Events()
+	adminCh := adminStream.Events()
+
+	t.Run("events are dispatched by topic", func(t *testing.T) {
+		created, err := public.Create(map[string]any{"field": "value_" + time.Now().Format(time.StampMilli)})
+		require.NoError(t, err)
+		e := <-publicCh
+		assert.Equal(t, "create", e.Action)
+		assert.Equal(t, created.ID, e.Record["id"])
+
+		created, err = admin.Create(map[string]any{"field": "value_" + time.Now().Format(time.StampMilli)})
+		require.NoError(t, err)
+		e = <-adminCh
+		assert.Equal(t, "create", e.Action)
+		assert.Equal(t, created.ID, e.Record["id"])
+	})
+
+	t.Run("unsubscribe updates subscriptions set", func(t *testing.T) {
+		publicStream.Unsubscribe()
+		assert.Equal(t, []string{migrations.PostsAdmin}, client.Realtime().Topics())
+
+		_, err := public.Create(map[string]any{"field": "value_" + time.Now().Format(time.StampMilli)})
+		require.NoError(t, err)
+		created, err := admin.Create(map[string]any{"field": "value_" + time.Now().Format(time.StampMilli)})
+		require.NoError(t, err)
+
+		e := <-adminCh
+		assert.Equal(t, created.ID, e.Record["id"])
+		_, ok := <-publicCh
+		assert.False(t, ok)
+		assert.Equal(t, int32(1), transport.connects.Load())
+	})
+
+	t.Run("connection is closed with the last stream", func(t *testing.T) {
+		adminStream.Unsubscribe()
+		assert.Empty(t, client.Realtime().Topics())
+
+		stream, err := public.SubscribeShared()
+		require.NoError(t, err)
+		defer stream.Unsubscribe()
+		<-stream.Ready()
+		assert.Equal(t, int32(2), transport.connects.Load())
+	})
+}
+
+func TestRealtime_SubscribeNoTopics(t *testing.T) {
+	client := NewClient(defaultURL)
+	_, err := Subscribe[map[string]any](client.Realtime())
+	assert.ErrorIs(t, err, ErrNoTopics)
+}
@@ -61,15 +61,11 @@ type (
 	}
 
 	Record struct {
+		BaseRecord
 		Avatar          string `json:"avatar"`
-		CollectionID    string `json:"collectionId"`
-		CollectionName  string `json:"collectionName"`
-		Created         string `json:"created"`
 		Email           string `json:"email"`
 		EmailVisibility bool   `json:"emailVisibility"`
-		ID              string `json:"id"`
 		Name            string `json:"name"`
-		Updated         string `json:"updated"`
 		Username        string `json:"username"`
 		Verified        bool   `json:"verified"`
 	}
@@ -167,15 +163,11 @@ func (c *Collection[T]) AuthWithOAuth2Code(provider string, code string, codeVer
 
 type AuthRefreshResponse struct {
 	Record struct {
+		BaseRecord
 		Avatar          string `json:"avatar"`
-		CollectionID    string `json:"collectionId"`
-		CollectionName  string `json:"collectionName"`
-		Created         string `json:"created"`
 		Email           string `json:"email"`
 		EmailVisibility bool   `json:"emailVisibility"`
-		ID              string `json:"id"`
 		Name            string `json:"name"`
-		Updated         string `json:"updated"`
 		Username        string `json:"username"`
 		Verified        bool   `json:"verified"`
 	} `json:"record"`
@@ -382,13 +374,13 @@ func (c *Collection[T]) ConfirmEmailChange(emailChangeToken string, password str
 }
 
 type ExternalAuthRequest struct {
-	ID           string `json:"id"`
-	Created      string `json:"created"`
-	Updated      string `json:"updated"`
-	RecordID     string `json:"recordId"`
-	CollectionID string `json:"collectionId"`
-	Provider     string `json:"provider"`
-	ProviderID   string `json:"providerId"`
+	ID           string   `json:"id"`
+	Created      DateTime `json:"created"`
+	Updated      DateTime `json:"updated"`
+	RecordID     string   `json:"recordId"`
+	CollectionID string   `json:"collectionId"`
+	Provider     string   `json:"provider"`
+	ProviderID   string   `json:"providerId"`
 }
 
 // ListExternalAuths lists all linked external auth providers for the specified auth record.
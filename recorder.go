@@ -0,0 +1,126 @@
+package pocketbase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// RecordedEvent is a line of a recording, see Recorder.
+type RecordedEvent struct {
+	Time  time.Time `json:"time"`
+	ID    string    `json:"id,omitempty"`
+	Event string    `json:"event"`
+	Data  string    `json:"data"`
+}
+
+// Recorder writes the realtime events received by streams as JSON lines, which can be replayed with Replay.
+// It's safe to share a recorder between streams.
+//
+// Example:
+//
+//	f, err := os.Create("posts.jsonl")
+//	recorder := pocketbase.NewRecorder(f)
+//	stream, err := collection.SubscribeWith(pocketbase.SubscribeOptions{Recorder: recorder})
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Err returns the first write error, the following events aren't recorded then.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(ev sseEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(RecordedEvent{
+		Time:  time.Now(),
+		ID:    ev.ID,
+		Event: ev.Event,
+		Data:  ev.Data,
+	})
+}
+
+type ReplayOptions struct {
+	// OriginalPace waits between the events as long as they were apart when recorded,
+	// they're replayed as fast as they're consumed by default.
+	OriginalPace bool
+	// BufferSize and OverflowPolicy work like in SubscribeOptions.
+	BufferSize     int
+	OverflowPolicy OverflowPolicy
+}
+
+// Replay delivers the events of a recording into a stream without a server, e.g. to test the consumers
+// of Collection.Subscribe. The stream is connected until the end of the recording and Err returns
+// the error of a malformed recording.
+//
+// Example:
+//
+//	f, err := os.Open("testdata/posts.jsonl")
+//	stream := pocketbase.Replay[Post](f, pocketbase.ReplayOptions{})
+//	for ev := range stream.Events() {
+//		...
+//	}
+func Replay[T any](r io.Reader, opts ReplayOptions) *Stream[T] {
+	stream := newStream[T](NewClient(""), SubscribeOptions{
+		BufferSize:     opts.BufferSize,
+		OverflowPolicy: opts.OverflowPolicy,
+	}, nil)
+	stream.unsubscribe = func() {}
+
+	go stream.replay(json.NewDecoder(r), opts.OriginalPace)
+	return stream
+}
+
+func (s *Stream[T]) replay(d *json.Decoder, originalPace bool) {
+	s.markReady()
+	s.sendState(StateChange{State: StateConnected, ClientID: "replay"})
+
+	var last time.Time
+	for {
+		var ev RecordedEvent
+		if err := d.Decode(&ev); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			} else {
+				err = fmt.Errorf("[replay] can't decode recording, err %w", err)
+			}
+			s.end(err)
+			return
+		}
+
+		if originalPace && !last.IsZero() {
+			select {
+			case <-s.done:
+				return
+			case <-time.After(ev.Time.Sub(last)):
+			}
+		}
+		last = ev.Time
+
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		s.dispatch(sseEvent{ID: ev.ID, Event: ev.Event, Data: ev.Data})
+	}
+}
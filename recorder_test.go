@@ -0,0 +1,106 @@
+package pocketbase
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	var recording bytes.Buffer
+	recorder := NewRecorder(&recording)
+	stream, err := collection.SubscribeWith(SubscribeOptions{Recorder: recorder})
+	require.NoError(t, err)
+	<-stream.Ready()
+
+	conn := server.conn(t)
+	ch := stream.Events()
+	for i := 0; i < 3; i++ {
+		conn.write(fmt.Sprintf("id:client1\nevent:posts\ndata:{\"action\":\"create\",\"record\":{\"n\":%d}}\n\n", i))
+		<-ch
+	}
+	stream.Unsubscribe()
+	require.NoError(t, recorder.Err())
+
+	var lines []RecordedEvent
+	scanner := bufio.NewScanner(bytes.NewReader(recording.Bytes()))
+	for scanner.Scan() {
+		var ev RecordedEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+		lines = append(lines, ev)
+	}
+	require.Len(t, lines, 3)
+	for i, ev := range lines {
+		assert.Equal(t, "client1", ev.ID)
+		assert.Equal(t, "posts", ev.Event)
+		assert.Equal(t, fmt.Sprintf(`{"action":"create","record":{"n":%d}}`, i), ev.Data)
+		assert.False(t, ev.Time.IsZero())
+	}
+
+	t.Run("replay as fast as possible", func(t *testing.T) {
+		replayed := Replay[map[string]any](bytes.NewReader(recording.Bytes()), ReplayOptions{BufferSize: 1})
+		<-replayed.Ready()
+		states := replayed.States()
+		assert.Equal(t, StateChange{State: StateConnected, ClientID: "replay"}, nextState(t, states))
+
+		var n []float64
+		for e := range replayed.Events() {
+			require.NoError(t, e.Error)
+			assert.Equal(t, ActionCreate, e.Action)
+			assert.Equal(t, "client1", e.ID)
+			n = append(n, e.Record["n"].(float64))
+		}
+		assert.Equal(t, []float64{0, 1, 2}, n)
+		assert.Equal(t, StateChange{State: StateClosed}, nextState(t, states))
+		assert.NoError(t, replayed.Err())
+	})
+}
+
+func TestReplay_OriginalPace(t *testing.T) {
+	const gap = 150 * time.Millisecond
+
+	var recording bytes.Buffer
+	enc := json.NewEncoder(&recording)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, enc.Encode(RecordedEvent{
+			Time:  start.Add(time.Duration(i) * gap),
+			Event: "posts",
+			Data:  fmt.Sprintf(`{"action":"update","record":{"n":%d}}`, i),
+		}))
+	}
+
+	stream := Replay[map[string]any](&recording, ReplayOptions{OriginalPace: true})
+	ch := stream.Events()
+	<-ch
+	first := time.Now()
+	<-ch
+	<-ch
+	assert.GreaterOrEqual(t, time.Since(first), 2*gap)
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestReplay_Malformed(t *testing.T) {
+	recording := `{"time":"2024-05-01T10:00:00Z","event":"posts","data":"{\"action\":\"delete\",\"record\":{}}"}` + "\nnot json\n"
+
+	stream := Replay[map[string]any](strings.NewReader(recording), ReplayOptions{})
+	var events []Event[map[string]any]
+	for e := range stream.Events() {
+		events = append(events, e)
+	}
+	require.Len(t, events, 1)
+	assert.Equal(t, ActionDelete, events[0].Action)
+	assert.Error(t, stream.Err())
+}
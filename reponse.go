@@ -1,5 +1,11 @@
 package pocketbase
 
+import (
+	"encoding/json"
+
+	"github.com/go-resty/resty/v2"
+)
+
 type ResponseList[T any] struct {
 	Page       int `json:"page"`
 	PerPage    int `json:"perPage"`
@@ -8,9 +14,46 @@ type ResponseList[T any] struct {
 	Items      []T `json:"items"`
 }
 
+// ResponseCreate is the created record returned by Client.Create.
+// Use Collection.CreateWithParams to get the full record.
 type ResponseCreate struct {
-	ID      string `json:"id"`
-	Created string `json:"created"`
-	Field   string `json:"field"`
-	Updated string `json:"updated"`
+	BaseRecord
+	// Deprecated: Field is a field of the test collections only, use Collection.CreateWithParams.
+	Field string `json:"field"`
+}
+
+// ResponseError is an error response of the record endpoints. It matches ErrInvalidResponse with errors.Is,
+// errors.As gives the status code and the validation errors.
+//
+// Example:
+//
+//	var respErr *pocketbase.ResponseError
+//	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+//		...
+//	}
+type ResponseError struct {
+	StatusCode int
+	Message    string `json:"message"`
+	// Data holds the validation errors by field, e.g. {"slug": {"code": "validation_not_unique", ...}}.
+	Data map[string]FieldError `json:"data"`
+}
+
+// FieldError is the validation error of a field.
+type FieldError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newResponseError(resp *resty.Response) *ResponseError {
+	e := &ResponseError{StatusCode: resp.StatusCode()}
+	_ = json.Unmarshal(resp.Body(), e)
+	return e
+}
+
+func (e *ResponseError) Error() string {
+	return ErrInvalidResponse.Error()
+}
+
+func (e *ResponseError) Unwrap() error {
+	return ErrInvalidResponse
 }
@@ -0,0 +1,376 @@
+package pocketbase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+var ErrNoSinks = errors.New("no sinks to deliver to")
+
+const (
+	// WebhookIDHeader carries the SinkEvent.ID of a webhook request.
+	WebhookIDHeader = "X-Webhook-Id"
+	// WebhookSignatureHeader carries the signature of a webhook request, see VerifyWebhook.
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// DefaultWebhookRetries is the number of retries of a failed webhook request when WebhookOptions.RetryStrategy is not set.
+	DefaultWebhookRetries = 5
+)
+
+// SinkEvent is a record change delivered to a Sink, marshalled as
+// {"id": ..., "time": ..., "collection": ..., "action": ..., "record": ...}.
+type SinkEvent struct {
+	// ID is derived from the collection, the action, and the id and updated date of the record, so an event
+	// delivered again, e.g. by the pending event of a Pipe checkpoint or a Watch which resumes from an older
+	// position, has the same ID and receivers can drop duplicates. It's random for records without an id.
+	ID         string          `json:"id"`
+	Time       time.Time       `json:"time"`
+	Collection string          `json:"collection"`
+	Action     string          `json:"action"`
+	Record     json.RawMessage `json:"record"`
+}
+
+// Sink receives the events forwarded by Pipe, e.g. to publish them on a message bus.
+// Send returns nil once the event is accepted.
+type Sink interface {
+	Send(ctx context.Context, ev SinkEvent) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(ctx context.Context, ev SinkEvent) error
+
+func (f SinkFunc) Send(ctx context.Context, ev SinkEvent) error {
+	return f(ctx, ev)
+}
+
+type WebhookOptions struct {
+	// Secret signs the requests with HMAC-SHA256, see VerifyWebhook. They're not signed without a secret.
+	Secret []byte
+	// Header is added to every request, e.g. an Authorization header.
+	Header http.Header
+	// RetryStrategy spaces the attempts of requests failed with a network error, 429 or 5xx.
+	// An exponential backoff limited to DefaultWebhookRetries retries by default.
+	RetryStrategy backoff.BackOff
+	// HTTPClient sends the requests, http.DefaultClient by default.
+	HTTPClient *http.Client
+}
+
+// WebhookSink posts the events as JSON to a URL. Sends are serialized, so the events arrive in order.
+type WebhookSink struct {
+	url  string
+	opts WebhookOptions
+	// mu guards the retry strategy, which keeps the state of an attempt
+	mu sync.Mutex
+}
+
+// NewWebhookSink returns a sink posting to the url, an event is accepted by a 2xx response.
+//
+// Example:
+//
+//	sink := pocketbase.NewWebhookSink("https://example.com/hooks/posts", pocketbase.WebhookOptions{
+//		Secret: []byte(os.Getenv("WEBHOOK_SECRET")),
+//	})
+func NewWebhookSink(url string, opts WebhookOptions) *WebhookSink {
+	if opts.RetryStrategy == nil {
+		opts.RetryStrategy = backoff.WithMaxRetries(backoff.NewExponentialBackOff(), DefaultWebhookRetries)
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	return &WebhookSink{
+		url:  url,
+		opts: opts,
+	}
+}
+
+func (w *WebhookSink) Send(ctx context.Context, ev SinkEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("[sink] can't marshal event, err %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	operation := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		for key, values := range w.opts.Header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(WebhookIDHeader, ev.ID)
+		if len(w.opts.Secret) > 0 {
+			req.Header.Set(WebhookSignatureHeader, SignWebhook(w.opts.Secret, body))
+		}
+
+		resp, err := w.opts.HTTPClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		code := resp.StatusCode
+		switch {
+		case code >= 200 && code < 300:
+			return nil
+		case code == http.StatusTooManyRequests || code >= 500:
+			return fmt.Errorf("[sink] webhook responded with status code %v", code)
+		default:
+			return backoff.Permanent(fmt.Errorf("[sink] webhook responded with status code %v", code))
+		}
+	}
+	return backoff.Retry(operation, backoff.WithContext(w.opts.RetryStrategy, ctx))
+}
+
+// SignWebhook returns the signature of a webhook body: "sha256=" and the hex encoded HMAC-SHA256.
+func SignWebhook(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the signature of a received webhook body, e.g. the X-Webhook-Signature header.
+func VerifyWebhook(secret []byte, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, body)), []byte(signature))
+}
+
+// JSONLSink writes the events as JSON lines. It's safe to share between pipes.
+type JSONLSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	return &JSONLSink{
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (s *JSONLSink) Send(_ context.Context, ev SinkEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.enc.Encode(ev); err != nil {
+		return fmt.Errorf("[sink] can't write event, err %w", err)
+	}
+	return nil
+}
+
+// FileSink appends the events as JSON lines to a file, which is synced after every event.
+type FileSink struct {
+	file  *os.File
+	jsonl *JSONLSink
+}
+
+// NewFileSink opens the file for appending, it's created if it doesn't exist.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("[sink] can't open %s, err %w", path, err)
+	}
+	return &FileSink{
+		file:  file,
+		jsonl: NewJSONLSink(file),
+	}, nil
+}
+
+func (s *FileSink) Send(ctx context.Context, ev SinkEvent) error {
+	if err := s.jsonl.Send(ctx, ev); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("[sink] can't sync %s, err %w", s.file.Name(), err)
+	}
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+type PipeOptions struct {
+	// Sinks receive every event in the given order.
+	Sinks []Sink
+	// Checkpoint is the path of a file keeping the event being delivered, which is delivered again
+	// by the next Pipe with the same checkpoint if it wasn't accepted by all sinks. Without a checkpoint
+	// the in-flight event is lost when the process stops.
+	Checkpoint string
+	// Position saves the "updated,id" position of every event accepted by all sinks. Piping a Watch with
+	// the same store as its WatchOptions.Checkpoint and WatchOptions.ManualCheckpoint resumes the watch
+	// after the last delivered event, so the events it buffered aren't lost when the process stops.
+	Position CheckpointStore
+}
+
+type pipeCheckpoint struct {
+	Pending *SinkEvent `json:"pending,omitempty"`
+}
+
+// Pipe delivers the events of the stream to the sinks one at a time: an event is delivered to all sinks
+// before the next one, and the in-flight event, which failed or was interrupted, is delivered again to all sinks
+// by the next Pipe with the same PipeOptions.Checkpoint. Events with an error are skipped.
+// It returns nil when ctx is done, the error of a failed delivery, or the stream error once it's terminated.
+//
+// The events buffered by the stream are lost when the process stops, unless it's a Watch resuming
+// from the PipeOptions.Position, which makes the delivery at-least-once.
+//
+// Example:
+//
+//	position := pocketbase.FileCheckpoint("posts.position")
+//	stream, err := collection.Watch(ctx, pocketbase.WatchOptions{
+//		Checkpoint:       position,
+//		ManualCheckpoint: true,
+//	})
+//	err = pocketbase.Pipe(ctx, stream, pocketbase.PipeOptions{
+//		Sinks:      []pocketbase.Sink{webhook},
+//		Checkpoint: "posts.pipe",
+//		Position:   position,
+//	})
+func Pipe[T any](ctx context.Context, stream *Stream[T], opts PipeOptions) error {
+	if len(opts.Sinks) == 0 {
+		return ErrNoSinks
+	}
+
+	pending, err := loadPipeCheckpoint(opts.Checkpoint)
+	if err != nil {
+		return err
+	}
+	if pending != nil {
+		if err := deliverToSinks(ctx, opts, *pending); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+
+	events := stream.Events()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return stream.Err()
+			}
+			if e.Error != nil {
+				stream.client.logger.Printf("[sink] skipping event, err %v", e.Error)
+				continue
+			}
+			ev, err := newSinkEvent(e, stream.topics)
+			if err != nil {
+				return err
+			}
+			if err := deliverToSinks(ctx, opts, ev); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+}
+
+func newSinkEvent[T any](e Event[T], topics []string) (SinkEvent, error) {
+	record, err := json.Marshal(e.Record)
+	if err != nil {
+		return SinkEvent{}, fmt.Errorf("[sink] can't marshal record, err %w", err)
+	}
+
+	var r struct {
+		ID             string `json:"id"`
+		CollectionName string `json:"collectionName"`
+		Updated        string `json:"updated"`
+	}
+	_ = json.Unmarshal(record, &r)
+	collection := r.CollectionName
+	if collection == "" && len(topics) == 1 {
+		collection, _ = splitTopic(topics[0])
+	}
+
+	id := make([]byte, 16)
+	if r.ID != "" {
+		sum := sha256.Sum256([]byte(collection + "\x00" + e.Action + "\x00" + r.ID + "\x00" + r.Updated))
+		copy(id, sum[:])
+	} else if _, err := rand.Read(id); err != nil {
+		return SinkEvent{}, fmt.Errorf("[sink] can't generate event id, err %w", err)
+	}
+
+	return SinkEvent{
+		ID:         hex.EncodeToString(id),
+		Time:       time.Now().UTC(),
+		Collection: collection,
+		Action:     e.Action,
+		Record:     record,
+	}, nil
+}
+
+func deliverToSinks(ctx context.Context, opts PipeOptions, ev SinkEvent) error {
+	if err := savePipeCheckpoint(opts.Checkpoint, &ev); err != nil {
+		return err
+	}
+	for _, sink := range opts.Sinks {
+		if err := sink.Send(ctx, ev); err != nil {
+			return fmt.Errorf("[sink] can't deliver event %s, err %w", ev.ID, err)
+		}
+	}
+	if opts.Position != nil {
+		// delete events of a watch only have the id of the record, so they don't move the position
+		var mark WatchMark
+		_ = json.Unmarshal(ev.Record, &mark)
+		if mark.Updated != "" && mark.ID != "" {
+			if err := opts.Position.Save(mark); err != nil {
+				return err
+			}
+		}
+	}
+	return savePipeCheckpoint(opts.Checkpoint, nil)
+}
+
+func loadPipeCheckpoint(path string) (*SinkEvent, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[checkpoint] can't read %s, err %w", path, err)
+	}
+	var c pipeCheckpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("[checkpoint] can't unmarshal %s, err %w", path, err)
+	}
+	return c.Pending, nil
+}
+
+func savePipeCheckpoint(path string, pending *SinkEvent) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(pipeCheckpoint{Pending: pending})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("[checkpoint] can't save %s, err %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package pocketbase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replayRecording returns a Replay recording of the events of the posts collection.
+func replayRecording(t *testing.T, records ...string) io.Reader {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, record := range records {
+		require.NoError(t, enc.Encode(RecordedEvent{
+			Time:  time.Now(),
+			Event: "posts",
+			Data:  `{"action":"create","record":` + record + `}`,
+		}))
+	}
+	return &b
+}
+
+func readSinkEvents(t *testing.T, r io.Reader) []SinkEvent {
+	var events []SinkEvent
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var ev SinkEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+		events = append(events, ev)
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestWebhookSink(t *testing.T) {
+	secret := []byte("secret")
+	var (
+		mu       sync.Mutex
+		attempts int
+		received []SinkEvent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if !VerifyWebhook(secret, body, r.Header.Get(WebhookSignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var ev SinkEvent
+		require.NoError(t, json.Unmarshal(body, &ev))
+		assert.Equal(t, ev.ID, r.Header.Get(WebhookIDHeader))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		received = append(received, ev)
+	}))
+	defer server.Close()
+
+	ev := SinkEvent{
+		ID:         "e1",
+		Time:       time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Collection: "posts",
+		Action:     ActionCreate,
+		Record:     json.RawMessage(`{"id":"p1"}`),
+	}
+
+	sink := NewWebhookSink(server.URL, WebhookOptions{
+		Secret:        secret,
+		Header:        http.Header{"Authorization": []string{"Bearer token"}},
+		RetryStrategy: backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2),
+	})
+	require.NoError(t, sink.Send(context.Background(), ev))
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []SinkEvent{ev}, received)
+
+	t.Run("client errors aren't retried", func(t *testing.T) {
+		attempts = 0
+		sink := NewWebhookSink(server.URL, WebhookOptions{
+			Secret:        []byte("wrong"),
+			RetryStrategy: backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2),
+		})
+		err := sink.Send(context.Background(), ev)
+		assert.ErrorContains(t, err, "status code 401")
+		assert.Equal(t, 0, attempts)
+	})
+
+	t.Run("retries are limited", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts++
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer failing.Close()
+
+		attempts = 0
+		sink := NewWebhookSink(failing.URL, WebhookOptions{
+			RetryStrategy: backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2),
+		})
+		err := sink.Send(context.Background(), ev)
+		assert.ErrorContains(t, err, "status code 429")
+		assert.Equal(t, 3, attempts)
+	})
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	events := []SinkEvent{
+		{ID: "e1", Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Action: ActionCreate, Record: json.RawMessage(`{"id":"p1"}`)},
+		{ID: "e2", Time: time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC), Action: ActionDelete, Record: json.RawMessage(`{"id":"p1"}`)},
+	}
+
+	// appends to the existing file
+	for _, ev := range events {
+		sink, err := NewFileSink(path)
+		require.NoError(t, err)
+		require.NoError(t, sink.Send(context.Background(), ev))
+		require.NoError(t, sink.Close())
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, events, readSinkEvents(t, f))
+}
+
+func TestPipe(t *testing.T) {
+	checkpoint := filepath.Join(t.TempDir(), "pipe.json")
+	var delivered bytes.Buffer
+	jsonl := NewJSONLSink(&delivered)
+
+	failing := errors.New("broker unavailable")
+	var sent []string
+	broker := SinkFunc(func(ctx context.Context, ev SinkEvent) error {
+		if strings.Contains(string(ev.Record), "p2") {
+			return failing
+		}
+		sent = append(sent, string(ev.Record))
+		return nil
+	})
+
+	stream := Replay[map[string]any](replayRecording(t, `{"id":"p1"}`, `{"id":"p2","collectionName":"other"}`, `{"id":"p3"}`), ReplayOptions{})
+	err := Pipe(context.Background(), stream, PipeOptions{
+		Sinks:      []Sink{jsonl, broker},
+		Checkpoint: checkpoint,
+	})
+	require.ErrorIs(t, err, failing)
+	assert.Equal(t, []string{`{"id":"p1"}`}, sent)
+
+	first := readSinkEvents(t, bytes.NewReader(delivered.Bytes()))
+	require.Len(t, first, 2)
+	assert.Equal(t, ActionCreate, first[0].Action)
+	assert.Equal(t, "other", first[1].Collection)
+	assert.NotEqual(t, first[0].ID, first[1].ID)
+
+	t.Run("the failed event is delivered again", func(t *testing.T) {
+		delivered.Reset()
+		broker := SinkFunc(func(ctx context.Context, ev SinkEvent) error {
+			sent = append(sent, string(ev.Record))
+			return nil
+		})
+
+		stream := Replay[map[string]any](replayRecording(t, `{"id":"p3"}`), ReplayOptions{})
+		err := Pipe(context.Background(), stream, PipeOptions{
+			Sinks:      []Sink{jsonl, broker},
+			Checkpoint: checkpoint,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`{"id":"p1"}`, `{"collectionName":"other","id":"p2"}`, `{"id":"p3"}`}, sent)
+
+		again := readSinkEvents(t, bytes.NewReader(delivered.Bytes()))
+		require.Len(t, again, 2)
+		assert.Equal(t, first[1], again[0])
+
+		pending, err := loadPipeCheckpoint(checkpoint)
+		require.NoError(t, err)
+		assert.Nil(t, pending)
+	})
+
+	t.Run("no sinks", func(t *testing.T) {
+		stream := Replay[map[string]any](replayRecording(t), ReplayOptions{})
+		assert.ErrorIs(t, Pipe(context.Background(), stream, PipeOptions{}), ErrNoSinks)
+		stream.Unsubscribe()
+	})
+}
+
+func TestPipe_Position(t *testing.T) {
+	position := FileCheckpoint(filepath.Join(t.TempDir(), "position.json"))
+	failing := errors.New("broker unavailable")
+	broker := SinkFunc(func(ctx context.Context, ev SinkEvent) error {
+		if strings.Contains(string(ev.Record), "p3") {
+			return failing
+		}
+		return nil
+	})
+
+	stream := Replay[map[string]any](replayRecording(t,
+		`{"id":"p1","updated":"2024-05-01 10:00:00.000Z"}`,
+		`{"id":"p2","updated":"2024-05-01 10:00:01.000Z"}`,
+		`{"id":"deleted"}`,
+		`{"id":"p3","updated":"2024-05-01 10:00:02.000Z"}`,
+	), ReplayOptions{})
+	err := Pipe(context.Background(), stream, PipeOptions{
+		Sinks:    []Sink{broker},
+		Position: position,
+	})
+	require.ErrorIs(t, err, failing)
+
+	// the last accepted event with a position, the failed one is delivered again after a restart
+	mark, err := position.Load()
+	require.NoError(t, err)
+	assert.Equal(t, WatchMark{Updated: "2024-05-01 10:00:01.000Z", ID: "p2"}, mark)
+}
+
+func TestNewSinkEvent_ID(t *testing.T) {
+	event := func(record map[string]any) SinkEvent {
+		ev, err := newSinkEvent(Event[map[string]any]{Action: ActionUpdate, Record: record}, []string{"posts"})
+		require.NoError(t, err)
+		return ev
+	}
+	record := map[string]any{"id": "p1", "updated": "2024-05-01 10:00:00.000Z"}
+
+	// a redelivered record change keeps its id
+	assert.Equal(t, event(record).ID, event(record).ID)
+	assert.NotEqual(t, event(record).ID, event(map[string]any{"id": "p1", "updated": "2024-05-01 10:00:01.000Z"}).ID)
+	assert.NotEqual(t, event(record).ID, event(map[string]any{"id": "p2", "updated": "2024-05-01 10:00:00.000Z"}).ID)
+	assert.Len(t, event(record).ID, 32)
+
+	// random without a record id
+	assert.NotEqual(t, event(map[string]any{"field": "a"}).ID, event(map[string]any{"field": "a"}).ID)
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"id":"e1"}`)
+	signature := SignWebhook([]byte("secret"), body)
+	assert.True(t, strings.HasPrefix(signature, "sha256="))
+	assert.True(t, VerifyWebhook([]byte("secret"), body, signature))
+	assert.False(t, VerifyWebhook([]byte("other"), body, signature))
+	assert.False(t, VerifyWebhook([]byte("secret"), []byte(`{"id":"e2"}`), signature))
+}
@@ -0,0 +1,128 @@
+package pocketbase
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"time"
+)
+
+// sseEvent is a single server-sent event.
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// sseDecoder reads server-sent events as described in
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation.
+// Lines and data are collected in reused buffers, so large payloads are read in linear time.
+type sseDecoder struct {
+	r      *bufio.Reader
+	line   []byte
+	data   bytes.Buffer
+	event  string
+	lastID string
+	retry  time.Duration
+	skipLF bool
+}
+
+func newSSEDecoder(r io.Reader) *sseDecoder {
+	return &sseDecoder{r: bufio.NewReaderSize(r, 32*1024)}
+}
+
+// Decode returns the next event. An incomplete event at the end of the stream is discarded.
+func (d *sseDecoder) Decode() (sseEvent, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return sseEvent{}, err
+		}
+
+		if len(line) == 0 {
+			if d.data.Len() == 0 {
+				d.event = ""
+				continue
+			}
+			ev := sseEvent{
+				ID:    d.lastID,
+				Event: d.event,
+				Data:  string(bytes.TrimSuffix(d.data.Bytes(), []byte("\n"))),
+			}
+			if ev.Event == "" {
+				ev.Event = "message"
+			}
+			d.data.Reset()
+			d.event = ""
+			return ev, nil
+		}
+
+		d.processField(line)
+	}
+}
+
+// Retry returns the reconnection time sent by the server, 0 if none was sent.
+func (d *sseDecoder) Retry() time.Duration {
+	return d.retry
+}
+
+func (d *sseDecoder) processField(line []byte) {
+	if line[0] == ':' {
+		return // comment
+	}
+
+	field, value := line, []byte(nil)
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		field, value = line[:i], line[i+1:]
+		value = bytes.TrimPrefix(value, []byte(" "))
+	}
+
+	switch string(field) {
+	case "event":
+		d.event = string(value)
+	case "data":
+		d.data.Write(value)
+		d.data.WriteByte('\n')
+	case "id":
+		if bytes.IndexByte(value, 0) < 0 {
+			d.lastID = string(value)
+		}
+	case "retry":
+		if ms, err := strconv.ParseUint(string(value), 10, 32); err == nil {
+			d.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+}
+
+// readLine reads a line ended by CRLF, LF or CR. The returned slice is valid until the next call.
+func (d *sseDecoder) readLine() ([]byte, error) {
+	d.line = d.line[:0]
+	for {
+		if d.r.Buffered() == 0 {
+			if _, err := d.r.Peek(1); err != nil {
+				return nil, err
+			}
+		}
+		buf, _ := d.r.Peek(d.r.Buffered())
+
+		if d.skipLF {
+			d.skipLF = false
+			if buf[0] == '\n' {
+				_, _ = d.r.Discard(1)
+				continue
+			}
+		}
+
+		i := bytes.IndexAny(buf, "\r\n")
+		if i < 0 {
+			d.line = append(d.line, buf...)
+			_, _ = d.r.Discard(len(buf))
+			continue
+		}
+		d.line = append(d.line, buf[:i]...)
+		d.skipLF = buf[i] == '\r'
+		_, _ = d.r.Discard(i + 1)
+		return d.line, nil
+	}
+}
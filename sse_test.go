@@ -0,0 +1,133 @@
+package pocketbase
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeAll(t testing.TB, r io.Reader) ([]sseEvent, *sseDecoder) {
+	d := newSSEDecoder(r)
+	var events []sseEvent
+	for {
+		ev, err := d.Decode()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			return events, d
+		}
+		events = append(events, ev)
+	}
+}
+
+func TestSSEDecoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+		events []sseEvent
+		retry  time.Duration
+	}{
+		{
+			name:   "PocketBase message",
+			stream: "id:client1\nevent:posts\ndata:{\"action\":\"create\"}\n\n",
+			events: []sseEvent{{ID: "client1", Event: "posts", Data: `{"action":"create"}`}},
+		},
+		{
+			name:   "Default event type and a leading space",
+			stream: "data: value\n\n",
+			events: []sseEvent{{Event: "message", Data: "value"}},
+		},
+		{
+			name:   "Multi-line data",
+			stream: "data:a\ndata:\ndata: b\n\n",
+			events: []sseEvent{{Event: "message", Data: "a\n\nb"}},
+		},
+		{
+			name:   "CRLF and CR line endings",
+			stream: "event:a\r\ndata:1\r\n\r\nevent:b\rdata:2\r\r",
+			events: []sseEvent{{Event: "a", Data: "1"}, {Event: "b", Data: "2"}},
+		},
+		{
+			name:   "Comments and unknown fields are ignored",
+			stream: ": keep-alive\nfoo:bar\ndata\n\n",
+			events: []sseEvent{{Event: "message"}},
+		},
+		{
+			name:   "Event without data is not dispatched",
+			stream: "event:empty\n\nevent:full\ndata:x\n\n",
+			events: []sseEvent{{Event: "full", Data: "x"}},
+		},
+		{
+			name:   "Id persists and NUL ids are ignored",
+			stream: "id:1\ndata:a\n\ndata:b\n\nid:2\x00\ndata:c\n\nid\ndata:d\n\n",
+			events: []sseEvent{
+				{ID: "1", Event: "message", Data: "a"},
+				{ID: "1", Event: "message", Data: "b"},
+				{ID: "1", Event: "message", Data: "c"},
+				{ID: "", Event: "message", Data: "d"},
+			},
+		},
+		{
+			name:   "Retry",
+			stream: "retry:1500\n\nretry:x\n\n",
+			retry:  1500 * time.Millisecond,
+		},
+		{
+			name:   "Incomplete event is discarded",
+			stream: "data:a\n\ndata:b\n",
+			events: []sseEvent{{Event: "message", Data: "a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, d := decodeAll(t, strings.NewReader(tt.stream))
+			assert.Equal(t, tt.events, events)
+			assert.Equal(t, tt.retry, d.Retry())
+
+			events, _ = decodeAll(t, iotest.OneByteReader(strings.NewReader(tt.stream)))
+			assert.Equal(t, tt.events, events)
+		})
+	}
+}
+
+func TestSSEDecoder_LargePayload(t *testing.T) {
+	data := strings.Repeat("x", 16<<20)
+	stream := "event:posts\ndata:" + data + "\n\nevent:comments\ndata:small\n\n"
+
+	events, _ := decodeAll(t, iotest.HalfReader(strings.NewReader(stream)))
+	require.Len(t, events, 2)
+	assert.Equal(t, len(data), len(events[0].Data))
+	assert.Equal(t, "small", events[1].Data)
+}
+
+func FuzzSSEDecoder(f *testing.F) {
+	f.Add("id:client1\nevent:PB_CONNECT\ndata:{\"clientId\":\"client1\"}\n\n")
+	f.Add("data:a\r\ndata:b\r\rretry:10\n:comment\n\n")
+	f.Add("\r\n\r\r\n\n:data\ndata\nid:\x00\n\n")
+
+	f.Fuzz(func(t *testing.T, stream string) {
+		events, d := decodeAll(t, strings.NewReader(stream))
+		chunked, dc := decodeAll(t, iotest.OneByteReader(strings.NewReader(stream)))
+		if len(events) != len(chunked) {
+			t.Fatalf("decoded %d events, but %d when read byte by byte", len(events), len(chunked))
+		}
+		for i := range events {
+			if events[i] != chunked[i] {
+				t.Fatalf("event %d differs when read byte by byte: %+v != %+v", i, events[i], chunked[i])
+			}
+			if events[i].Event == "" {
+				t.Fatalf("event %d has no type", i)
+			}
+		}
+		if d.Retry() != dc.Retry() {
+			t.Fatalf("retry differs when read byte by byte: %v != %v", d.Retry(), dc.Retry())
+		}
+	})
+}
@@ -3,20 +3,33 @@ package pocketbase
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/SierraSoftworks/multicast/v2"
 	"github.com/cenkalti/backoff/v4"
-	"github.com/donovanhide/eventsource"
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	// ActionResync marks a gap after which deleted records may be missing, see SubscribeOptions.CatchUpResync.
+	ActionResync = "resync"
 )
 
 type Event[T any] struct {
 	Action string `json:"action"`
 	Record T      `json:"record"`
-	Error  error  `json:"-"`
+	// ID is the SSE event id, PocketBase sends the realtime client id.
+	ID    string `json:"-"`
+	Error error  `json:"-"`
 }
 
 func (c *Collection[T]) Subscribe(targets ...string) (*Stream[T], error) {
@@ -26,8 +39,52 @@ func (c *Collection[T]) Subscribe(targets ...string) (*Stream[T], error) {
 	return c.SubscribeWith(opts, targets...)
 }
 
+// DefaultBufferSize is the number of events buffered per stream when SubscribeOptions.BufferSize is not set.
+const DefaultBufferSize = 64
+
+var ErrSlowConsumer = errors.New("slow consumer, stream buffer overflow")
+
+// OverflowPolicy decides what happens with new events when the stream buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock stops reading from the realtime connection until the consumer catches up.
+	// Nothing is lost, but on a shared connection a slow stream delays all the others.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest buffered event to make room for the new one.
+	OverflowDropOldest
+	// OverflowDropNewest discards the new event.
+	OverflowDropNewest
+	// OverflowDisconnect unsubscribes the stream with ErrSlowConsumer.
+	OverflowDisconnect
+)
+
 type SubscribeOptions struct {
+	// ReconnectStrategy spaces the reconnect attempts, they're immediate by default.
+	// When the server sent a retry interval, reconnecting waits at least that long.
 	ReconnectStrategy backoff.BackOff
+	// BufferSize is the number of events buffered for a slow consumer, DefaultBufferSize by default.
+	BufferSize int
+	// OverflowPolicy is applied when the buffer is full, OverflowBlock by default.
+	OverflowPolicy OverflowPolicy
+	// CatchUp queries the subscribed collections after every reconnect for records updated
+	// since the last received event (or since the newest record at the first connect, if there was none)
+	// and delivers them as create/update events before the live events.
+	// Events can be delivered twice around the reconnect, and deleted records can't be recovered.
+	CatchUp bool
+	// CatchUpResync delivers an ActionResync event before the caught up events,
+	// so consumers can resync the full state to drop records deleted in the meantime.
+	CatchUpResync bool
+	// Recorder captures the received events, see Replay.
+	Recorder *Recorder
+	// IdleTimeout forces a reconnect when nothing was received for the given time,
+	// which detects half-open connections. PocketBase closes connections without messages
+	// after 5 minutes, so it should be set above that, unless the server sends keep-alive messages.
+	// Disabled by default.
+	IdleTimeout time.Duration
+	// KeepAlive sets the TCP keep-alive period of the realtime connection.
+	// The keep-alive settings of the client transport are used by default.
+	KeepAlive time.Duration
 }
 
 func (c *Collection[T]) SubscribeWith(opts SubscribeOptions, targets ...string) (*Stream[T], error) {
@@ -39,119 +96,624 @@ func (c *Collection[T]) SubscribeWith(opts SubscribeOptions, targets ...string)
 		targets = []string{c.Name}
 	}
 
-	stream := newStream[T]()
-	ctx, cancel := context.WithCancel(context.Background())
-	stream.unsubscribe = func() { cancel() }
+	stream := newStream[T](c.Client, opts, targets)
+	conn := newRealtimeConn(c.Client, opts, func() []string { return targets }, stream)
+	stream.unsubscribe = conn.close
 
-	handleSSEEvent := func(ev eventsource.Event) {
-		var e Event[T]
-		e.Error = json.Unmarshal([]byte(ev.Data()), &e)
-		stream.channel.C <- e
+	if err := conn.start(); err != nil {
+		stream.discard()
+		return nil, err
 	}
 
-	once := &sync.Once{}
-	stream.ready.Lock()
-	startStream := func(check bool) func() error {
-		return func() (err error) {
-			req := c.client.R().SetContext(ctx).SetDoNotParseResponse(true)
-			resp, err := req.Get(c.url + "/api/realtime")
-			defer resp.RawBody().Close()
-			if err != nil {
-				return
-			}
+	return stream, nil
+}
 
-			d := eventsource.NewDecoder(resp.RawBody())
+type SubscriptionsSet struct {
+	ClientID      string   `json:"clientId"`
+	Subscriptions []string `json:"subscriptions"`
+}
 
-			ev, err := d.Decode()
-			if err != nil {
-				return err
+func (c *Client) authSubscribeStream(clientID string, targets []string) (err error) {
+	s := SubscriptionsSet{
+		ClientID:      clientID,
+		Subscriptions: targets,
+	}
+	resp, err := c.client.R().SetBody(s).Post(c.url + "/api/realtime")
+	if err != nil {
+		return
+	}
+	if code := resp.StatusCode(); code != http.StatusNoContent {
+		return fmt.Errorf("auth subscribe stream failed. resp status code is %v", code)
+	}
+	return
+}
+
+// ConnectionState is the state of the realtime connection behind a stream.
+type ConnectionState int
+
+const (
+	// StateConnected is reported once the connection is established and subscriptions are posted.
+	StateConnected ConnectionState = iota + 1
+	// StateDisconnected is reported when an established connection drops.
+	StateDisconnected
+	// StateReconnecting is reported before every reconnect attempt.
+	StateReconnecting
+	// StateClosed is the final state, reported after unsubscribing or when reconnecting gave up.
+	StateClosed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("ConnectionState(%d)", int(s))
+	}
+}
+
+// StateChange describes a change of the realtime connection state.
+type StateChange struct {
+	State ConnectionState
+	// ClientID is the realtime client id, set for StateConnected.
+	ClientID string
+	// Attempt is the reconnect attempt number starting from 1, set for StateReconnecting.
+	Attempt int
+	// Err is the cause for StateDisconnected and the final error for StateClosed
+	// (nil if the stream was unsubscribed).
+	Err error
+}
+
+// realtimeConn is a single SSE connection to /api/realtime which keeps reconnecting
+// until it's closed and re-posts its subscriptions after every (re)connect.
+type realtimeConn struct {
+	client     *Client
+	http       *resty.Client
+	opts       SubscribeOptions
+	topics     func() []string
+	subscriber realtimeSubscriber
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// authChanged signals a token change to watchAuthChanges
+	authChanged chan struct{}
+
+	subscribeMu  sync.Mutex
+	clientID     string
+	body         io.Closer
+	restartCause error
+	// retry is the last reconnection time sent by the server
+	retry time.Duration
+}
+
+func newRealtimeConn(client *Client, opts SubscribeOptions, topics func() []string, subscriber realtimeSubscriber) *realtimeConn {
+	if opts.ReconnectStrategy == nil {
+		opts.ReconnectStrategy = &backoff.ZeroBackOff{}
+	}
+	httpClient := client.client
+	if opts.KeepAlive > 0 {
+		httpClient = newKeepAliveClient(client.client, opts.KeepAlive)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &realtimeConn{
+		client:      client,
+		http:        httpClient,
+		opts:        opts,
+		topics:      topics,
+		subscriber:  subscriber,
+		ctx:         ctx,
+		cancel:      cancel,
+		authChanged: make(chan struct{}, 1),
+	}
+}
+
+// start connects synchronously, so connection and subscription errors are
+// returned to the caller, and then listens and reconnects in the background.
+func (r *realtimeConn) start() error {
+	body, d, err := r.connect()
+	if err != nil {
+		r.cancel()
+		return err
+	}
+	r.client.watchAuth(r)
+	go r.watchAuthChanges()
+
+	go func() {
+		for {
+			err := r.listen(body, d)
+			if retry := d.Retry(); retry > 0 {
+				r.retry = retry
 			}
-			if event := ev.Event(); event != "PB_CONNECT" {
-				return fmt.Errorf("first event must be PB_CONNECT, but got %s", event)
+			if r.ctx.Err() != nil {
+				return
 			}
-
-			if err := c.authSubscribeStream([]byte(ev.Data()), targets); err != nil {
-				return err
+			if cause := r.takeRestartCause(); cause != nil {
+				err = cause
 			}
+			r.changeState(StateChange{State: StateDisconnected, Err: err})
 
-			if !check {
-				once.Do(func() {
-					stream.ready.Unlock()
-				})
-				for {
-					ev, err := d.Decode()
-					if err != nil {
-						return err
-					}
-					go handleSSEEvent(ev)
+			body, d, err = r.reconnect()
+			if err != nil {
+				if r.ctx.Err() == nil {
+					r.client.logger.Printf("[realtime] reconnecting failed, err %v", err)
+					r.subscriber.changeState(StateChange{State: StateClosed, Err: err})
 				}
+				r.close()
+				return
 			}
-
-			return nil
 		}
-	}
+	}()
 
-	if err := startStream(true)(); err != nil {
-		return nil, err
+	return nil
+}
+
+func (r *realtimeConn) reconnect() (body io.ReadCloser, d *sseDecoder, err error) {
+	strategy := r.opts.ReconnectStrategy
+	if r.retry > 0 {
+		select {
+		case <-r.ctx.Done():
+			return nil, nil, r.ctx.Err()
+		case <-time.After(r.retry):
+		}
+		strategy = &retryBackOff{BackOff: strategy, retry: r.retry}
 	}
 
-	go func() {
-		if err := backoff.Retry(startStream(false), backoff.WithContext(opts.ReconnectStrategy, ctx)); err != nil {
-			log.Print(err)
+	attempt := 0
+	operation := func() error {
+		if err := r.ctx.Err(); err != nil {
+			return backoff.Permanent(err)
 		}
-	}()
+		attempt++
+		r.changeState(StateChange{State: StateReconnecting, Attempt: attempt})
 
-	return stream, nil
+		var err error
+		body, d, err = r.connect()
+		return err
+	}
+	err = backoff.Retry(operation, backoff.WithContext(strategy, r.ctx))
+	return
 }
 
-type SubscriptionsSet struct {
-	ClientID      string   `json:"clientId"`
-	Subscriptions []string `json:"subscriptions"`
+// retryBackOff waits at least the reconnection time sent by the server between the attempts.
+type retryBackOff struct {
+	backoff.BackOff
+	retry time.Duration
+}
+
+func (b *retryBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next != backoff.Stop && next < b.retry {
+		return b.retry
+	}
+	return next
 }
 
-func (c *Collection[T]) authSubscribeStream(data []byte, targets []string) (err error) {
+func (r *realtimeConn) connect() (io.ReadCloser, *sseDecoder, error) {
+	r.setClientID("")
+	// a reconnect may need a refreshed token
+	if err := r.client.Authorize(); err != nil {
+		return nil, nil, err
+	}
+
+	req := r.http.R().SetContext(r.ctx).SetDoNotParseResponse(true)
+	if r.http != r.client.client {
+		req.SetHeaderMultiValues(r.client.client.Header)
+	}
+	resp, err := req.Get(r.client.url + "/api/realtime")
+	if err != nil {
+		return nil, nil, err
+	}
+	body := resp.RawBody()
+	if r.opts.IdleTimeout > 0 {
+		body = newIdleTimeoutReader(body, r.opts.IdleTimeout)
+	}
+
+	d := newSSEDecoder(body)
+	ev, err := d.Decode()
+	if err != nil {
+		body.Close()
+		return nil, nil, err
+	}
+	if event := ev.Event; event != "PB_CONNECT" {
+		body.Close()
+		return nil, nil, fmt.Errorf("first event must be PB_CONNECT, but got %s", event)
+	}
+
 	var s SubscriptionsSet
-	if err = json.Unmarshal(data, &s); err != nil {
+	if err := json.Unmarshal([]byte(ev.Data), &s); err != nil {
+		body.Close()
+		return nil, nil, err
+	}
+	r.setClientID(s.ClientID)
+
+	if err := r.subscribe(); err != nil {
+		body.Close()
+		return nil, nil, err
+	}
+	r.subscribeMu.Lock()
+	r.body = body
+	r.subscribeMu.Unlock()
+	r.changeState(StateChange{State: StateConnected, ClientID: s.ClientID})
+
+	return body, d, nil
+}
+
+func (r *realtimeConn) listen(body io.ReadCloser, d *sseDecoder) error {
+	defer body.Close()
+	for {
+		ev, err := d.Decode()
+		if err != nil {
+			return err
+		}
+		r.subscriber.dispatch(ev)
+	}
+}
+
+// changeState reports state changes of a connection which wasn't closed on purpose.
+func (r *realtimeConn) changeState(sc StateChange) {
+	if r.ctx.Err() != nil {
 		return
 	}
-	s.Subscriptions = targets
-	resp, err := c.client.R().SetBody(s).Post(c.url + "/api/realtime")
-	if err != nil {
+	r.subscriber.changeState(sc)
+}
+
+func (r *realtimeConn) setClientID(clientID string) {
+	r.subscribeMu.Lock()
+	defer r.subscribeMu.Unlock()
+	r.clientID = clientID
+}
+
+// restart closes the current connection, so it's reconnected with the given cause.
+func (r *realtimeConn) restart(cause error) {
+	r.subscribeMu.Lock()
+	defer r.subscribeMu.Unlock()
+
+	if r.body == nil {
 		return
 	}
-	if code := resp.StatusCode(); code != http.StatusNoContent {
-		return fmt.Errorf("auth subscribe stream failed. resp status code is %v", code)
+	r.restartCause = cause
+	r.clientID = ""
+	_ = r.body.Close()
+}
+
+func (r *realtimeConn) takeRestartCause() error {
+	r.subscribeMu.Lock()
+	defer r.subscribeMu.Unlock()
+
+	cause := r.restartCause
+	r.restartCause = nil
+	r.body = nil
+	return cause
+}
+
+func (r *realtimeConn) currentClientID() string {
+	r.subscribeMu.Lock()
+	defer r.subscribeMu.Unlock()
+	return r.clientID
+}
+
+// subscribe posts the current subscriptions set. It's a no-op while disconnected,
+// because the subscriptions are posted anyway once the connection is established.
+func (r *realtimeConn) subscribe() error {
+	r.subscribeMu.Lock()
+	defer r.subscribeMu.Unlock()
+
+	if r.clientID == "" {
+		return nil
 	}
-	return
+	if err := r.client.authSubscribeStream(r.clientID, r.topics()); err != nil {
+		return err
+	}
+	r.subscriber.markReady()
+	return nil
+}
+
+func (r *realtimeConn) close() {
+	r.cancel()
+	r.client.unwatchAuth(r)
 }
 
+// stateBufferSize is the number of state changes buffered for a slow consumer of Stream.States,
+// the oldest are discarded first.
+const stateBufferSize = 16
+
+// Stream delivers events in the order they were received. Events are buffered
+// according to SubscribeOptions.BufferSize and SubscribeOptions.OverflowPolicy
+// until they're read from the Events channel.
 type Stream[T any] struct {
 	channel     *multicast.Channel[Event[T]]
 	unsubscribe func()
+	client      *Client
+	topics      []string
+
+	queue      chan Event[T]
+	policy     OverflowPolicy
+	dropped    atomic.Uint64
+	done       chan struct{}
+	listening  chan struct{}
+	onceListen *sync.Once
+	eof        chan struct{}
+	eofErr     error
+	recorder   *Recorder
+	filter     atomic.Pointer[func(Event[T]) bool]
+
+	handlersMu   sync.RWMutex
+	handlers     map[string][]func(T)
+	errHandlers  []func(error)
+	onceHandlers *sync.Once
+
+	stateMu      sync.Mutex
+	states       chan StateChange
+	statesClosed bool
+	err          error
+
+	catchUp       bool
+	catchUpResync bool
+	catchUpMu     sync.Mutex
+	lastSeen      WatchMark
+	connectMarks  map[string]WatchMark
+	gap           bool
 
 	ready       *sync.RWMutex
+	onceReady   *sync.Once
 	onceCleanup *sync.Once
 }
 
-func newStream[T any]() *Stream[T] {
-	return &Stream[T]{
-		channel:     multicast.New[Event[T]](),
-		ready:       &sync.RWMutex{},
-		onceCleanup: &sync.Once{},
+func newStream[T any](client *Client, opts SubscribeOptions, topics []string) *Stream[T] {
+	size := opts.BufferSize
+	if size <= 0 {
+		size = DefaultBufferSize
 	}
+	s := &Stream[T]{
+		channel:       multicast.New[Event[T]](),
+		client:        client,
+		topics:        topics,
+		queue:         make(chan Event[T], size),
+		policy:        opts.OverflowPolicy,
+		done:          make(chan struct{}),
+		listening:     make(chan struct{}),
+		eof:           make(chan struct{}),
+		recorder:      opts.Recorder,
+		onceListen:    &sync.Once{},
+		handlers:      map[string][]func(T){},
+		onceHandlers:  &sync.Once{},
+		states:        make(chan StateChange, stateBufferSize),
+		catchUp:       opts.CatchUp,
+		catchUpResync: opts.CatchUpResync,
+		connectMarks:  map[string]WatchMark{},
+		ready:         &sync.RWMutex{},
+		onceReady:     &sync.Once{},
+		onceCleanup:   &sync.Once{},
+	}
+	s.ready.Lock()
+	go s.deliver()
+	return s
 }
 
-func (s *Stream[T]) Events() <-chan Event[T] {
-	return s.channel.Listen().C
+func (s *Stream[T]) dispatch(ev sseEvent) {
+	if s.recorder != nil {
+		s.recorder.record(ev)
+	}
+	var e Event[T]
+	e.Error = json.Unmarshal([]byte(ev.Data), &e)
+	e.ID = ev.ID
+	if s.catchUp {
+		s.see([]byte(ev.Data))
+	}
+	s.push(e)
 }
 
-func (s *Stream[T]) Unsubscribe() {
+func (s *Stream[T]) push(e Event[T]) {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	switch s.policy {
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.queue <- e:
+				return
+			default:
+			}
+			select {
+			case <-s.queue:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	case OverflowDropNewest:
+		select {
+		case s.queue <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	case OverflowDisconnect:
+		select {
+		case s.queue <- e:
+		default:
+			s.dropped.Add(1)
+			s.client.logger.Printf("[realtime] stream disconnected, err %v", ErrSlowConsumer)
+			go s.close(ErrSlowConsumer)
+		}
+	default:
+		select {
+		case s.queue <- e:
+		case <-s.done:
+		}
+	}
+}
+
+// deliver is the only sender on the multicast channel, which keeps the events ordered.
+// It waits for the first listener, so the events stay buffered until then.
+func (s *Stream[T]) deliver() {
+	defer s.channel.Close()
+
+	select {
+	case <-s.done:
+		return
+	case <-s.listening:
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		select {
+		case <-s.done:
+			return
+		case e := <-s.queue:
+			if !s.send(e) {
+				return
+			}
+		case <-s.eof:
+			for {
+				select {
+				case e := <-s.queue:
+					if !s.send(e) {
+						return
+					}
+				default:
+					s.close(s.eofErr)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Stream[T]) send(e Event[T]) bool {
+	if filter := s.filter.Load(); filter != nil && !(*filter)(e) {
+		return true
+	}
+	select {
+	case <-s.done:
+		return false
+	case s.channel.C <- e:
+		return true
+	}
+}
+
+// end terminates the stream with the given error once the buffered events are delivered.
+// Nothing may be pushed afterwards.
+func (s *Stream[T]) end(err error) {
+	s.eofErr = err
+	close(s.eof)
+}
+
+func (s *Stream[T]) changeState(sc StateChange) {
+	switch sc.State {
+	case StateClosed:
+		s.close(sc.Err)
+		return
+	case StateDisconnected:
+		s.markGap()
+	case StateConnected:
+		if s.catchUp {
+			// called before any live event of the connection is dispatched
+			defer s.catchUpGap()
+		}
+	}
+	s.sendState(sc)
+}
+
+// sendState never blocks the connection, when the buffer is full the oldest state change is discarded.
+func (s *Stream[T]) sendState(sc StateChange) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if s.statesClosed {
+		return
+	}
+	for {
+		select {
+		case s.states <- sc:
+			return
+		default:
+		}
+		select {
+		case <-s.states:
+		default:
+		}
+	}
+}
+
+// close terminates the stream with the final error, nil if it was unsubscribed.
+func (s *Stream[T]) close(err error) {
 	s.onceCleanup.Do(func() {
+		close(s.done)
 		s.unsubscribe()
-		s.channel.Close()
+		s.markReady()
+
+		s.sendState(StateChange{State: StateClosed, Err: err})
+		s.stateMu.Lock()
+		s.err = err
+		s.statesClosed = true
+		close(s.states)
+		s.stateMu.Unlock()
+	})
+}
+
+// discard stops the delivery of a stream which was never handed out to the caller.
+func (s *Stream[T]) discard() {
+	s.onceCleanup.Do(func() {
+		close(s.done)
 	})
 }
 
+func (s *Stream[T]) topicError(_ string, err error) {
+	s.push(Event[T]{Error: err})
+}
+
+func (s *Stream[T]) markReady() {
+	s.onceReady.Do(func() {
+		s.ready.Unlock()
+	})
+}
+
+// Dropped returns the number of events discarded because of the overflow policy.
+func (s *Stream[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Stream[T]) Events() <-chan Event[T] {
+	l := s.channel.Listen()
+	s.onceListen.Do(func() {
+		close(s.listening)
+	})
+	return l.C
+}
+
+// States returns the connection state changes of the stream. The channel is closed
+// after the final StateClosed change. Note that it's the same channel for all callers,
+// and the oldest changes are discarded when they're not read.
+func (s *Stream[T]) States() <-chan StateChange {
+	return s.states
+}
+
+// Err returns the error which terminated the stream, e.g. ErrSlowConsumer or the last
+// reconnect error. It's nil while the stream is running or after Unsubscribe.
+func (s *Stream[T]) Err() error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.err
+}
+
+func (s *Stream[T]) Unsubscribe() {
+	s.close(nil)
+}
+
 // Deprecated: use <-stream.Ready() instead of
 func (s *Stream[T]) WaitAuthReady() error {
 	s.ready.RLock()
@@ -159,6 +721,7 @@ func (s *Stream[T]) WaitAuthReady() error {
 	return nil
 }
 
+// Ready is closed once the subscriptions are posted or the stream is terminated.
 func (s *Stream[T]) Ready() <-chan struct{} {
 	readyCh := make(chan struct{})
 	go func() {
@@ -1,12 +1,21 @@
 package pocketbase
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/zcharym/pocketbase-client/migrations"
 )
 
@@ -15,7 +24,11 @@ func TestCollection_Subscribe(t *testing.T) {
 	defaultBody := map[string]interface{}{
 		"field": "value_" + time.Now().Format(time.StampMilli),
 	}
-	collection := Collection[map[string]any]{client, migrations.PostsPublic, client.url + "/api/collections/collectionname"}
+	collection := Collection[map[string]any]{
+		Client:             client,
+		Name:               migrations.PostsPublic,
+		BaseCollectionPath: client.url + "/api/collections/collectionname",
+	}
 	stream, err := collection.Subscribe()
 	if err != nil {
 		t.Error(err)
@@ -80,7 +93,11 @@ func TestCollection_Unsubscribe(t *testing.T) {
 	defaultBody := map[string]interface{}{
 		"field": "value_" + time.Now().Format(time.StampMilli),
 	}
-	collection := Collection[map[string]any]{client, migrations.PostsPublic, client.url + "/api/collections/collectionname"}
+	collection := Collection[map[string]any]{
+		Client:             client,
+		Name:               migrations.PostsPublic,
+		BaseCollectionPath: client.url + "/api/collections/collectionname",
+	}
 	stream, err := collection.Subscribe()
 	if err != nil {
 		t.Error(err)
@@ -131,7 +148,11 @@ func TestCollection_RealtimeReconnect(t *testing.T) {
 	defaultBody := map[string]interface{}{
 		"field": "value_" + time.Now().Format(time.StampMilli),
 	}
-	collection := Collection[map[string]any]{client, migrations.PostsPublic, client.url + "/api/collections/collectionname"}
+	collection := Collection[map[string]any]{
+		Client:             client,
+		Name:               migrations.PostsPublic,
+		BaseCollectionPath: client.url + "/api/collections/collectionname",
+	}
 	stream, err := collection.Subscribe()
 	if err != nil {
 		t.Error(err)
@@ -159,3 +180,541 @@ func TestCollection_RealtimeReconnect(t *testing.T) {
 	}
 	assert.Equal(t, true, got)
 }
+
+func TestStream_Ordering(t *testing.T) {
+	const total = 2000
+
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.SubscribeWith(SubscribeOptions{BufferSize: 8})
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+	ch := stream.Events()
+
+	conn := server.conn(t)
+	go func() {
+		for i := 0; i < total; i++ {
+			conn.send("posts", fmt.Sprintf(`{"action":"create","record":{"n":%d}}`, i))
+		}
+	}()
+
+	for i := 0; i < total; i++ {
+		e := <-ch
+		require.NoError(t, e.Error)
+		require.Equal(t, float64(i), e.Record["n"])
+		if i%100 == 0 {
+			time.Sleep(time.Millisecond) // slow consumer
+		}
+	}
+	assert.Zero(t, stream.Dropped())
+}
+
+func TestStream_OrderingShared(t *testing.T) {
+	const total = 1000
+
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+
+	posts, err := SubscribeWith[map[string]any](client.Realtime(), SubscribeOptions{BufferSize: 4}, "posts")
+	require.NoError(t, err)
+	defer posts.Unsubscribe()
+	comments, err := SubscribeWith[map[string]any](client.Realtime(), SubscribeOptions{BufferSize: 4}, "comments")
+	require.NoError(t, err)
+	defer comments.Unsubscribe()
+	<-posts.Ready()
+	<-comments.Ready()
+
+	conn := server.conn(t)
+	go func() {
+		for i := 0; i < total; i++ {
+			conn.send("posts", fmt.Sprintf(`{"action":"create","record":{"n":%d}}`, i))
+			conn.send("comments", fmt.Sprintf(`{"action":"create","record":{"n":%d}}`, i))
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, stream := range []*Stream[map[string]any]{posts, comments} {
+		ch := stream.Events()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < total; i++ {
+				e := <-ch
+				assert.Equal(t, float64(i), e.Record["n"])
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStream_BlockedSharedUnsubscribe(t *testing.T) {
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+
+	posts, err := SubscribeWith[map[string]any](client.Realtime(), SubscribeOptions{BufferSize: 1}, "posts")
+	require.NoError(t, err)
+	defer posts.Unsubscribe()
+	comments, err := Subscribe[map[string]any](client.Realtime(), "comments")
+	require.NoError(t, err)
+	<-posts.Ready()
+	<-comments.Ready()
+
+	conn := server.conn(t)
+	for i := 0; i < 5; i++ {
+		conn.send("posts", fmt.Sprintf(`{"action":"create","record":{"n":%d}}`, i))
+	}
+	assert.Eventually(t, func() bool { return len(posts.queue) == cap(posts.queue) }, time.Second, 10*time.Millisecond)
+
+	// posts is never read, so its push blocks the connection, but not the other streams
+	unsubscribed := make(chan struct{})
+	go func() {
+		comments.Unsubscribe()
+		close(unsubscribed)
+	}()
+	select {
+	case <-unsubscribed:
+	case <-time.After(time.Second):
+		t.Fatal("unsubscribe blocked by a full stream")
+	}
+	assert.Equal(t, []string{"posts"}, client.Realtime().Topics())
+}
+
+func TestStream_OverflowPolicy(t *testing.T) {
+	const total = 50
+
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+		check  func(t *testing.T, received []float64)
+	}{
+		{
+			name:   "Drop newest keeps the first events",
+			policy: OverflowDropNewest,
+			check: func(t *testing.T, received []float64) {
+				assert.Equal(t, float64(0), received[0])
+			},
+		},
+		{
+			name:   "Drop oldest keeps the last events",
+			policy: OverflowDropOldest,
+			check: func(t *testing.T, received []float64) {
+				assert.Equal(t, float64(total-1), received[len(received)-1])
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeRealtime(t)
+			client := NewClient(server.URL)
+
+			stream, err := SubscribeWith[map[string]any](client.Realtime(), SubscribeOptions{BufferSize: 2, OverflowPolicy: tt.policy}, "posts")
+			require.NoError(t, err)
+			defer stream.Unsubscribe()
+			sentinel, err := Subscribe[map[string]any](client.Realtime(), "sentinel")
+			require.NoError(t, err)
+			defer sentinel.Unsubscribe()
+			<-stream.Ready()
+			<-sentinel.Ready()
+
+			conn := server.conn(t)
+			for i := 0; i < total; i++ {
+				conn.send("posts", fmt.Sprintf(`{"action":"create","record":{"n":%d}}`, i))
+			}
+			// events are dispatched one by one, so all posts are handled once the sentinel arrives
+			conn.send("sentinel", `{"action":"create","record":{}}`)
+			<-sentinel.Events()
+
+			dropped := stream.Dropped()
+			assert.NotZero(t, dropped)
+
+			ch := stream.Events()
+			received := make([]float64, 0, total)
+			for i := 0; i < total-int(dropped); i++ {
+				e := <-ch
+				received = append(received, e.Record["n"].(float64))
+			}
+			assert.IsIncreasing(t, received)
+			tt.check(t, received)
+		})
+	}
+}
+
+func TestStream_OverflowDisconnect(t *testing.T) {
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+
+	stream, err := SubscribeWith[map[string]any](client.Realtime(), SubscribeOptions{BufferSize: 2, OverflowPolicy: OverflowDisconnect}, "posts")
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+
+	conn := server.conn(t)
+	for i := 0; i < 10; i++ {
+		conn.send("posts", fmt.Sprintf(`{"action":"create","record":{"n":%d}}`, i))
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(client.Realtime().Topics()) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.NotZero(t, stream.Dropped())
+	for e := range stream.Events() { // drain until closed
+		assert.NoError(t, e.Error)
+	}
+}
+
+func TestStream_States(t *testing.T) {
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.Subscribe()
+	require.NoError(t, err)
+	<-stream.Ready()
+	states := stream.States()
+
+	assert.Equal(t, StateChange{State: StateConnected, ClientID: "client1"}, nextState(t, states))
+
+	server.conn(t).close()
+	sc := nextState(t, states)
+	assert.Equal(t, StateDisconnected, sc.State)
+	assert.Error(t, sc.Err)
+	assert.Equal(t, StateChange{State: StateReconnecting, Attempt: 1}, nextState(t, states))
+	assert.Equal(t, StateChange{State: StateConnected, ClientID: "client2"}, nextState(t, states))
+	server.conn(t)
+
+	stream.Unsubscribe()
+	assert.Equal(t, StateChange{State: StateClosed}, nextState(t, states))
+	_, ok := <-states
+	assert.False(t, ok)
+	assert.NoError(t, stream.Err())
+}
+
+func TestStream_ReconnectGaveUp(t *testing.T) {
+	server := newFakeRealtime(t)
+	logger := &testLogger{}
+	client := NewClient(server.URL, WithLogger(logger), WithRealtimeOptions(SubscribeOptions{
+		ReconnectStrategy: backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2),
+	}))
+	client.client.SetRetryCount(0)
+
+	t.Run("own connection", func(t *testing.T) {
+		stream, err := CollectionSet[map[string]any](client, "posts").SubscribeWith(SubscribeOptions{
+			ReconnectStrategy: backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2),
+		})
+		require.NoError(t, err)
+		<-stream.Ready()
+
+		server.refuse.Store(true)
+		defer server.refuse.Store(false)
+		server.conn(t).close()
+
+		var last StateChange
+		for sc := range stream.States() {
+			last = sc
+		}
+		assert.Equal(t, StateClosed, last.State)
+		assert.Error(t, last.Err)
+		assert.Equal(t, last.Err, stream.Err())
+		_, ok := <-stream.Events()
+		assert.False(t, ok)
+		assert.Contains(t, logger.String(), "reconnecting failed")
+	})
+
+	t.Run("shared connection", func(t *testing.T) {
+		posts, err := Subscribe[map[string]any](client.Realtime(), "posts")
+		require.NoError(t, err)
+		comments, err := Subscribe[map[string]any](client.Realtime(), "comments")
+		require.NoError(t, err)
+		<-posts.Ready()
+		<-comments.Ready()
+
+		server.refuse.Store(true)
+		defer server.refuse.Store(false)
+		server.conn(t).close()
+
+		for _, stream := range []*Stream[map[string]any]{posts, comments} {
+			_, ok := <-stream.Events()
+			assert.False(t, ok)
+			assert.Error(t, stream.Err())
+		}
+		assert.Empty(t, client.Realtime().Topics())
+	})
+}
+
+func TestStream_RetryHint(t *testing.T) {
+	const retry = 300 * time.Millisecond
+
+	server := newFakeRealtime(t)
+	client := NewClient(server.URL)
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.Subscribe()
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+	states := stream.States()
+	assert.Equal(t, StateConnected, nextState(t, states).State)
+
+	conn := server.conn(t)
+	conn.write(fmt.Sprintf("retry:%d\nid:client1\nevent:posts\ndata:{\"action\":\"create\",\"record\":{}}\n\n", retry.Milliseconds()))
+	e := <-stream.Events()
+	assert.Equal(t, "client1", e.ID)
+
+	conn.close()
+	assert.Equal(t, StateDisconnected, nextState(t, states).State)
+	disconnected := time.Now()
+	assert.Equal(t, StateChange{State: StateReconnecting, Attempt: 1}, nextState(t, states))
+	assert.GreaterOrEqual(t, time.Since(disconnected), retry)
+	assert.Equal(t, StateChange{State: StateConnected, ClientID: "client2"}, nextState(t, states))
+	server.conn(t)
+}
+
+// catchUpRecords serves the newest record for the first connect and the items for catching up.
+func catchUpRecords(filter *atomic.Value) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sort") == "-updated,-id" {
+			_, _ = io.WriteString(w, `{"page":1,"perPage":1,"totalItems":1,"totalPages":1,"items":[
+				{"id":"z","created":"2023-12-31 09:00:00.000Z","updated":"2023-12-31 09:00:00.000Z"}
+			]}`)
+			return
+		}
+		filter.Store(r.URL.Query().Get("filter"))
+		_, _ = io.WriteString(w, `{"page":1,"perPage":500,"totalItems":2,"totalPages":1,"items":[
+			{"id":"a","field":"created","created":"2024-01-01 10:00:01.000Z","updated":"2024-01-01 10:00:01.000Z"},
+			{"id":"b","field":"updated","created":"2023-12-31 10:00:00.000Z","updated":"2024-01-01 10:00:02.000Z"}
+		]}`)
+	}
+}
+
+func TestStream_CatchUp(t *testing.T) {
+	server := newFakeRealtime(t)
+	var filter atomic.Value
+	server.records = catchUpRecords(&filter)
+	client := NewClient(server.URL)
+	collection := CollectionSet[map[string]any](client, "posts")
+
+	stream, err := collection.SubscribeWith(SubscribeOptions{CatchUp: true, CatchUpResync: true})
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+	ch := stream.Events()
+
+	conn := server.conn(t)
+	conn.send("posts", `{"action":"update","record":{"id":"b","updated":"2024-01-01 10:00:00.000Z"}}`)
+	assert.Equal(t, ActionUpdate, (<-ch).Action)
+	conn.close()
+
+	conn = server.conn(t)
+	conn.send("posts", `{"action":"delete","record":{"id":"c","updated":"2024-01-01 10:00:03.000Z"}}`)
+
+	e := <-ch
+	assert.Equal(t, ActionResync, e.Action)
+	e = <-ch
+	assert.Equal(t, ActionCreate, e.Action)
+	assert.Equal(t, "a", e.Record["id"])
+	e = <-ch
+	assert.Equal(t, ActionUpdate, e.Action)
+	assert.Equal(t, "b", e.Record["id"])
+	e = <-ch
+	assert.Equal(t, ActionDelete, e.Action)
+	assert.Equal(t, "c", e.Record["id"])
+
+	assert.Equal(t, "(updated > '2024-01-01 10:00:00.000Z' || (updated = '2024-01-01 10:00:00.000Z' && id > 'b'))", filter.Load())
+}
+
+func TestStream_CatchUpFromConnect(t *testing.T) {
+	server := newFakeRealtime(t)
+	var filter atomic.Value
+	server.records = catchUpRecords(&filter)
+	client := NewClient(server.URL)
+
+	stream, err := SubscribeWith[map[string]any](client.Realtime(), SubscribeOptions{CatchUp: true}, "posts/a")
+	require.NoError(t, err)
+	defer stream.Unsubscribe()
+	<-stream.Ready()
+	ch := stream.Events()
+
+	server.conn(t).close()
+	server.conn(t)
+
+	e := <-ch
+	assert.Equal(t, ActionCreate, e.Action)
+	assert.Equal(t, "a", e.Record["id"])
+	// the newest record by the server's clock, not the connect time by the client's clock
+	assert.Equal(t, "(updated > '2023-12-31 09:00:00.000Z' || (updated = '2023-12-31 09:00:00.000Z' && id > 'z')) && (id = 'a')", filter.Load())
+}
+
+func TestSplitTopic(t *testing.T) {
+	tests := []struct {
+		topic      string
+		collection string
+		id         string
+	}{
+		{topic: "posts", collection: "posts"},
+		{topic: "posts/*", collection: "posts", id: "*"},
+		{topic: "posts/abc", collection: "posts", id: "abc"},
+		{topic: `posts/abc?options={"query":{"expand":"author"}}`, collection: "posts", id: "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			collection, id := splitTopic(tt.topic)
+			assert.Equal(t, tt.collection, collection)
+			assert.Equal(t, tt.id, id)
+		})
+	}
+}
+
+func nextState(t *testing.T, states <-chan StateChange) StateChange {
+	select {
+	case sc := <-states:
+		return sc
+	case <-time.After(5 * time.Second):
+		t.Fatal("no state change")
+		return StateChange{}
+	}
+}
+
+type testLogger struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (l *testLogger) Printf(format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+func (l *testLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+// fakeRealtime imitates the realtime API of PocketBase, so tests have full control over the SSE stream.
+type fakeRealtime struct {
+	*httptest.Server
+	conns    chan *fakeRealtimeConn
+	connects atomic.Int32
+	refuse   atomic.Bool
+	records  http.HandlerFunc
+
+	connectHeader atomic.Value
+
+	mu            sync.Mutex
+	subscriptions map[string][]string
+	auths         map[string]string
+}
+
+type fakeRealtimeConn struct {
+	id     string
+	frames chan string
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newFakeRealtime(t *testing.T) *fakeRealtime {
+	f := &fakeRealtime{
+		conns:         make(chan *fakeRealtimeConn, 16),
+		subscriptions: map[string][]string{},
+		auths:         map[string]string{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// conn returns the next established connection.
+func (f *fakeRealtime) conn(t *testing.T) *fakeRealtimeConn {
+	select {
+	case c := <-f.conns:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("no realtime connection established")
+		return nil
+	}
+}
+
+func (f *fakeRealtime) handle(w http.ResponseWriter, r *http.Request) {
+	if f.records != nil && strings.HasPrefix(r.URL.Path, "/api/collections/") {
+		f.records(w, r)
+		return
+	}
+	if r.URL.Path != "/api/realtime" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		var s SubscriptionsSet
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		// like PocketBase, the identity of a client can't change, tokens are "identity:version" here
+		if prev, ok := f.auths[s.ClientID]; ok && identityOf(prev) != identityOf(auth) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f.auths[s.ClientID] = auth
+		f.subscriptions[s.ClientID] = s.Subscriptions
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	f.connectHeader.Store(r.Header.Clone())
+	if f.refuse.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	conn := &fakeRealtimeConn{
+		id:     fmt.Sprintf("client%d", f.connects.Add(1)),
+		frames: make(chan string),
+		closed: make(chan struct{}),
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	_, _ = fmt.Fprintf(w, "id:%s\nevent:PB_CONNECT\ndata:{\"clientId\":\"%s\"}\n\n", conn.id, conn.id)
+	w.(http.Flusher).Flush()
+	f.conns <- conn
+
+	for {
+		select {
+		case frame := <-conn.frames:
+			_, _ = io.WriteString(w, frame)
+			w.(http.Flusher).Flush()
+		case <-conn.closed:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func identityOf(token string) string {
+	identity, _, _ := strings.Cut(token, ":")
+	return identity
+}
+
+func (c *fakeRealtimeConn) send(topic, data string) {
+	c.write("event:" + topic + "\ndata:" + data + "\n\n")
+}
+
+func (c *fakeRealtimeConn) write(frame string) {
+	select {
+	case c.frames <- frame:
+	case <-c.closed:
+	}
+}
+
+func (c *fakeRealtimeConn) close() {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+}
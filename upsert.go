@@ -0,0 +1,141 @@
+package pocketbase
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// upsertAttempts limits the attempts of an upsert racing with other creates and deletes.
+const upsertAttempts = 3
+
+// ErrInvalidID is returned by Upsert for an id PocketBase doesn't accept.
+var ErrInvalidID = errors.New("id must be 15 lowercase letters or digits")
+
+// Upsert updates the record with the id, or creates it with the id if it doesn't exist, and reports
+// whether it was created. The id is a PocketBase id: 15 characters a-z and 0-9, e.g. derived from
+// the key of the record in another system. The body is a Patch or another map, or a struct whose
+// unset Optional fields are left out, like in UpdateFields.
+//
+// A create which fails, because the record was created meanwhile, is retried as an update.
+// Other errors, e.g. validation errors of the body, are returned right away.
+//
+// Example:
+//
+//	post, created, err := posts.Upsert("ext000000012345", Post{Title: "hello"})
+func (c *Collection[T]) Upsert(id string, body any) (T, bool, error) {
+	var response T
+
+	if !validID(id) {
+		return response, false, fmt.Errorf("[upsert] invalid id %q, err %w", id, ErrInvalidID)
+	}
+	patch, err := patchOf(body)
+	if err != nil {
+		return response, false, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		response, err = c.updateRecord(id, patch, ParamsList{})
+		if !isStatus(err, http.StatusNotFound) {
+			return response, false, err
+		}
+
+		create := patch.With(Patch{"id": id})
+		response, err = c.createRecord(create, ParamsList{})
+		if err == nil || !isUniqueConflict(err, "id") || attempt == upsertAttempts {
+			return response, err == nil, err
+		}
+		// created meanwhile, unless the update fails again
+	}
+}
+
+// UpsertBy updates the record whose field has the value, or creates it with the value if there's none,
+// and reports whether it was created. The field should have a unique index, which makes PocketBase
+// reject a second record created meanwhile with "validation_not_unique", the create is retried as an update then.
+//
+// Example:
+//
+//	post, created, err := posts.UpsertBy("slug", "hello-world", pocketbase.Patch{"title": "Hello world"})
+func (c *Collection[T]) UpsertBy(field string, value any, body any) (T, bool, error) {
+	var response T
+
+	patch, err := patchOf(body)
+	if err != nil {
+		return response, false, err
+	}
+	filter := fmt.Sprintf("%s = %s", field, filterValue(value))
+	lookup := CollectionSet[DynRecord](c.Client, c.Name)
+
+	for attempt := 1; ; attempt++ {
+		existing, err := lookup.First(ParamsList{Filters: filter, Fields: "id"})
+		switch {
+		case err == nil:
+			response, err = c.updateRecord(existing.GetString("id"), patch, ParamsList{})
+			// deleted meanwhile
+			if isStatus(err, http.StatusNotFound) && attempt < upsertAttempts {
+				continue
+			}
+			return response, false, err
+		case !errors.Is(err, ErrNotFound):
+			return response, false, err
+		}
+
+		response, err = c.createRecord(patch.With(Patch{field: value}), ParamsList{})
+		if err == nil || !isUniqueConflict(err, field) || attempt == upsertAttempts {
+			return response, err == nil, err
+		}
+		// created meanwhile, unless it isn't found again
+	}
+}
+
+// validID reports whether the id can be used as a custom record id.
+func validID(id string) bool {
+	if len(id) != 15 {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// filterValue formats the value as a filter operand.
+func filterValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return "'" + escapeFilterValue(v) + "'"
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	default:
+		return "'" + escapeFilterValue(fmt.Sprint(v)) + "'"
+	}
+}
+
+// isUniqueConflict reports whether a create failed only because a record with the same value of the field exists.
+// A create racing with another one for the same id fails on the primary key, without field errors.
+func isUniqueConflict(err error, field string) bool {
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	if len(respErr.Data) == 0 {
+		return field == "id"
+	}
+	for name, fieldErr := range respErr.Data {
+		unique := fieldErr.Code == "validation_not_unique" || (field == "id" && fieldErr.Code == "validation_invalid_id")
+		if name != field || !unique {
+			return false
+		}
+	}
+	return true
+}
+
+func isStatus(err error, code int) bool {
+	var respErr *ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == code
+}
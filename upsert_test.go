@@ -0,0 +1,228 @@
+package pocketbase
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zcharym/pocketbase-client/migrations"
+)
+
+type uniquePost struct {
+	BaseRecord
+	Field string `json:"field"`
+	Slug  string `json:"slug"`
+}
+
+// testID returns a record id unique to the test run.
+func testID(prefix string) string {
+	id := fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+	return id[len(id)-15:]
+}
+
+func TestCollection_Upsert(t *testing.T) {
+	collection := CollectionSet[uniquePost](NewClient(defaultURL), migrations.PostsPublic)
+
+	id := testID("u")
+	created, isNew, err := collection.Upsert(id, uniquePost{Field: "first"})
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, collection.Delete(id)) }()
+	assert.True(t, isNew)
+	assert.Equal(t, id, created.ID)
+	assert.Equal(t, "first", created.Field)
+
+	updated, isNew, err := collection.Upsert(id, Patch{"field": "second"})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, "second", updated.Field)
+
+	_, _, err = collection.Upsert("Not-An-Id", Patch{})
+	assert.ErrorIs(t, err, ErrInvalidID)
+
+	t.Run("concurrent", func(t *testing.T) {
+		id := testID("c")
+		var creates atomic.Int32
+		var wg sync.WaitGroup
+		for i := range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, isNew, err := collection.Upsert(id, Patch{"field": fmt.Sprint(i)})
+				assert.NoError(t, err)
+				if isNew {
+					creates.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), creates.Load())
+		assert.NoError(t, collection.Delete(id))
+	})
+}
+
+func TestCollection_UpsertBy(t *testing.T) {
+	collection := CollectionSet[uniquePost](NewClient(defaultURL), migrations.PostsUnique)
+
+	slug := "it's-" + testID("s")
+	created, isNew, err := collection.UpsertBy("slug", slug, Patch{"field": "first"})
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, collection.Delete(created.ID)) }()
+	assert.True(t, isNew)
+	assert.Equal(t, slug, created.Slug)
+
+	updated, isNew, err := collection.UpsertBy("slug", slug, uniquePost{Field: "second", Slug: slug})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, "second", updated.Field)
+
+	_, _, err = collection.UpsertBy("missing_field", slug, Patch{})
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+
+	t.Run("concurrent", func(t *testing.T) {
+		slug := "concurrent-" + testID("s")
+		var (
+			creates atomic.Int32
+			mu      sync.Mutex
+			ids     = map[string]bool{}
+			wg      sync.WaitGroup
+		)
+		for i := range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				record, isNew, err := collection.UpsertBy("slug", slug, Patch{"field": fmt.Sprint(i)})
+				if !assert.NoError(t, err) {
+					return
+				}
+				if isNew {
+					creates.Add(1)
+				}
+				mu.Lock()
+				ids[record.ID] = true
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), creates.Load())
+		require.Len(t, ids, 1)
+		for id := range ids {
+			assert.NoError(t, collection.Delete(id))
+		}
+	})
+}
+
+// upsertServer serves a collection without records, which rejects every create with the error.
+func upsertServer(t *testing.T, createError string) (*httptest.Server, *atomic.Int32) {
+	var creates atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			creates.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(createError))
+		case http.MethodPatch:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":404,"message":"The requested resource wasn't found.","data":{}}`))
+		default:
+			_, _ = w.Write([]byte(`{"page":1,"perPage":1,"totalItems":0,"totalPages":0,"items":[]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &creates
+}
+
+func TestCollection_UpsertErrors(t *testing.T) {
+	const (
+		required  = `{"code":400,"message":"Failed to create record.","data":{"field":{"code":"validation_required","message":"Missing required value."}}}`
+		notUnique = `{"code":400,"message":"Failed to create record.","data":{"slug":{"code":"validation_not_unique","message":"Value must be unique."}}}`
+	)
+
+	t.Run("invalid body isn't retried", func(t *testing.T) {
+		server, creates := upsertServer(t, required)
+		collection := CollectionSet[uniquePost](NewClient(server.URL), "posts")
+
+		_, _, err := collection.Upsert("abc123def456ghi", Patch{"field": ""})
+		var respErr *ResponseError
+		require.True(t, errors.As(err, &respErr))
+		assert.Equal(t, "validation_required", respErr.Data["field"].Code)
+		assert.Equal(t, int32(1), creates.Load())
+
+		creates.Store(0)
+		_, _, err = collection.UpsertBy("slug", "hello", Patch{"field": ""})
+		require.True(t, errors.As(err, &respErr))
+		assert.Equal(t, "validation_required", respErr.Data["field"].Code)
+		assert.Equal(t, int32(1), creates.Load())
+	})
+
+	t.Run("unique conflict is retried", func(t *testing.T) {
+		server, creates := upsertServer(t, notUnique)
+		collection := CollectionSet[uniquePost](NewClient(server.URL), "posts")
+
+		_, _, err := collection.UpsertBy("slug", "hello", Patch{"field": "x"})
+		assert.ErrorIs(t, err, ErrInvalidResponse)
+		assert.Equal(t, int32(upsertAttempts), creates.Load())
+	})
+}
+
+func TestIsUniqueConflict(t *testing.T) {
+	conflict := func(data map[string]FieldError) error {
+		return fmt.Errorf("[create] failed, err %w", &ResponseError{StatusCode: http.StatusBadRequest, Data: data})
+	}
+	tests := []struct {
+		name  string
+		err   error
+		field string
+		want  bool
+	}{
+		{name: "Not unique", err: conflict(map[string]FieldError{"slug": {Code: "validation_not_unique"}}), field: "slug", want: true},
+		{name: "Existing id", err: conflict(map[string]FieldError{"id": {Code: "validation_invalid_id"}}), field: "id", want: true},
+		{name: "Primary key", err: conflict(nil), field: "id", want: true},
+		{name: "No field errors", err: conflict(nil), field: "slug"},
+		{name: "Other field", err: conflict(map[string]FieldError{"title": {Code: "validation_not_unique"}}), field: "slug"},
+		{name: "Also invalid", err: conflict(map[string]FieldError{"slug": {Code: "validation_not_unique"}, "title": {Code: "validation_required"}}), field: "slug"},
+		{name: "Required", err: conflict(map[string]FieldError{"slug": {Code: "validation_required"}}), field: "slug"},
+		{name: "Not found", err: &ResponseError{StatusCode: http.StatusNotFound}, field: "id"},
+		{name: "Other error", err: errors.New("network"), field: "id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isUniqueConflict(tt.err, tt.field))
+		})
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	collection := CollectionSet[uniquePost](NewClient(defaultURL), migrations.PostsUnique)
+
+	_, err := collection.One("missing")
+	var respErr *ResponseError
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, http.StatusNotFound, respErr.StatusCode)
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+	assert.True(t, strings.HasSuffix(err.Error(), "err invalid response"))
+
+	_, err = collection.CreateWithParams(uniquePost{BaseRecord: BaseRecord{ID: "bad id"}}, ParamsList{})
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, http.StatusBadRequest, respErr.StatusCode)
+	assert.Contains(t, respErr.Data, "id")
+}
+
+func TestFilterValue(t *testing.T) {
+	assert.Equal(t, `'it\'s'`, filterValue("it's"))
+	assert.Equal(t, "42", filterValue(42))
+	assert.Equal(t, "1.5", filterValue(1.5))
+	assert.Equal(t, "true", filterValue(true))
+	assert.Equal(t, "'2024-05-01 10:00:00.000Z'", filterValue(NewDateTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))))
+	assert.True(t, validID("abc123def456ghi"))
+	assert.False(t, validID("abc123def456gh"))
+	assert.False(t, validID("ABC123DEF456GHI"))
+}
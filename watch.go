@@ -0,0 +1,344 @@
+package pocketbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval of Watch when WatchOptions.Interval is not set.
+const DefaultWatchInterval = 5 * time.Second
+
+const watchPageSize = 500
+
+// WatchMark is the position of a watch: the last seen record in the "updated,id" order.
+type WatchMark struct {
+	Updated string `json:"updated"`
+	ID      string `json:"id"`
+}
+
+// precedes reports whether a record with the created date and id was created after the mark
+// in the "updated,id" order, so it wasn't seen before. The zero mark precedes all records.
+func (m WatchMark) precedes(created DateTime, id string) bool {
+	// unparsable marks are treated like the zero mark
+	updated, _ := parseDateTime(m.Updated)
+	return created.After(updated.Time) || (created.Equal(updated.Time) && id > m.ID)
+}
+
+// CheckpointStore persists the position of a watch, so it resumes after a restart.
+type CheckpointStore interface {
+	// Load returns the saved mark, the zero mark if none was saved yet.
+	Load() (WatchMark, error)
+	Save(mark WatchMark) error
+}
+
+type fileCheckpoint struct {
+	path string
+}
+
+// FileCheckpoint stores the mark as JSON in the file, which is replaced atomically on save.
+func FileCheckpoint(path string) CheckpointStore {
+	return fileCheckpoint{path: path}
+}
+
+func (f fileCheckpoint) Load() (WatchMark, error) {
+	var mark WatchMark
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return mark, nil
+	}
+	if err != nil {
+		return mark, fmt.Errorf("[checkpoint] can't read %s, err %w", f.path, err)
+	}
+	if err := json.Unmarshal(data, &mark); err != nil {
+		return mark, fmt.Errorf("[checkpoint] can't unmarshal %s, err %w", f.path, err)
+	}
+	return mark, nil
+}
+
+func (f fileCheckpoint) Save(mark WatchMark) error {
+	data, err := json.Marshal(mark)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(f.path, data); err != nil {
+		return fmt.Errorf("[checkpoint] can't save %s, err %w", f.path, err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file with a renamed temporary file, so readers never see a partial write.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type WatchOptions struct {
+	// Interval between the polls, DefaultWatchInterval by default.
+	Interval time.Duration
+	// Filter limits the watched records, e.g. "status = 'active'".
+	Filter string
+	// Checkpoint persists the position. Without a saved position the watch starts
+	// after the newest record, like a realtime subscription.
+	Checkpoint CheckpointStore
+	// ManualCheckpoint only loads the position from the Checkpoint, or saves the starting one,
+	// and leaves saving the position of the handled events to the consumer, see PipeOptions.Position.
+	// Otherwise the position is saved once the events are buffered, so they're lost when the process stops.
+	ManualCheckpoint bool
+	// DeleteInterval is the interval of comparing the ids of all watched records,
+	// which detects deleted records. The ids are held in memory. Disabled by default.
+	// Records which stop matching the Filter are reported as deleted too.
+	DeleteInterval time.Duration
+	// BufferSize and OverflowPolicy work like in SubscribeOptions.
+	BufferSize     int
+	OverflowPolicy OverflowPolicy
+}
+
+type watcher[T any] struct {
+	collection *Collection[T]
+	opts       WatchOptions
+	stream     *Stream[T]
+	mark       WatchMark
+	// ids of the watched records, nil when deletes aren't detected
+	ids map[string]struct{}
+}
+
+type watchRecord struct {
+	ID      string   `json:"id"`
+	Created DateTime `json:"created"`
+	// Updated is kept as sent, the mark is compared with it in filters
+	Updated string `json:"updated"`
+}
+
+// Watch polls the collection for changes and delivers them like a realtime stream,
+// for environments where SSE connections don't work. Records are listed sorted by "updated,id"
+// after the last seen one, so changes between the polls are merged into a single event.
+// The watch is stopped by ctx or Unsubscribe.
+//
+// Example:
+//
+//	stream, err := collection.Watch(ctx, pocketbase.WatchOptions{
+//		Interval:   10 * time.Second,
+//		Checkpoint: pocketbase.FileCheckpoint("posts.checkpoint"),
+//	})
+func (c *Collection[T]) Watch(ctx context.Context, opts WatchOptions) (*Stream[T], error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+
+	w := &watcher[T]{
+		collection: c,
+		opts:       opts,
+	}
+	if err := w.start(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w.stream = newStream[T](c.Client, SubscribeOptions{
+		BufferSize:     opts.BufferSize,
+		OverflowPolicy: opts.OverflowPolicy,
+	}, []string{c.Name})
+	w.stream.unsubscribe = cancel
+	w.stream.markReady()
+	w.stream.sendState(StateChange{State: StateConnected})
+
+	go func() {
+		// unblocks the delivery to a stalled consumer
+		<-ctx.Done()
+		w.stream.close(nil)
+	}()
+	go w.run(ctx)
+	return w.stream, nil
+}
+
+// start loads the position and the ids before the first poll.
+func (w *watcher[T]) start() error {
+	if w.opts.Checkpoint != nil {
+		mark, err := w.opts.Checkpoint.Load()
+		if err != nil {
+			return err
+		}
+		w.mark = mark
+	}
+	if w.mark == (WatchMark{}) {
+		newest, err := w.list(WatchMark{}, "-updated,-id", 1)
+		if err != nil {
+			return err
+		}
+		if len(newest) > 0 {
+			var r watchRecord
+			if err := json.Unmarshal(newest[0], &r); err != nil {
+				return fmt.Errorf("[watch] can't unmarshal record, err %w", err)
+			}
+			w.mark = WatchMark{Updated: r.Updated, ID: r.ID}
+		}
+		// the consumer resumes from the start until it saves a position
+		if w.opts.Checkpoint != nil && w.opts.ManualCheckpoint && w.mark != (WatchMark{}) {
+			if err := w.opts.Checkpoint.Save(w.mark); err != nil {
+				return err
+			}
+		}
+	}
+
+	if w.opts.DeleteInterval > 0 {
+		ids, err := w.fetchIDs()
+		if err != nil {
+			return err
+		}
+		w.ids = ids
+	}
+	return nil
+}
+
+func (w *watcher[T]) run(ctx context.Context) {
+	defer w.stream.close(nil)
+
+	poll := time.NewTicker(w.opts.Interval)
+	defer poll.Stop()
+	var deletes <-chan time.Time
+	if w.opts.DeleteInterval > 0 {
+		ticker := time.NewTicker(w.opts.DeleteInterval)
+		defer ticker.Stop()
+		deletes = ticker.C
+	}
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			err = w.poll()
+		case <-deletes:
+			err = w.checkDeletes()
+		}
+		if err != nil && ctx.Err() == nil {
+			w.collection.logger.Printf("[watch] polling %s failed, err %v", w.collection.Name, err)
+			w.stream.push(Event[T]{Error: err})
+		}
+	}
+}
+
+// poll delivers the records updated after the mark.
+func (w *watcher[T]) poll() error {
+	since := w.mark
+	for {
+		items, err := w.list(w.mark, "updated,id", watchPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, raw := range items {
+			var r watchRecord
+			if err := json.Unmarshal(raw, &r); err != nil {
+				return fmt.Errorf("[watch] can't unmarshal record, err %w", err)
+			}
+
+			e := Event[T]{Action: ActionUpdate}
+			if since.precedes(r.Created, r.ID) {
+				e.Action = ActionCreate
+			}
+			e.Error = json.Unmarshal(raw, &e.Record)
+			if w.ids != nil {
+				w.ids[r.ID] = struct{}{}
+			}
+			w.stream.push(e)
+			w.mark = WatchMark{Updated: r.Updated, ID: r.ID}
+		}
+
+		if len(items) > 0 && w.opts.Checkpoint != nil && !w.opts.ManualCheckpoint {
+			if err := w.opts.Checkpoint.Save(w.mark); err != nil {
+				return err
+			}
+		}
+		if len(items) < watchPageSize {
+			return nil
+		}
+	}
+}
+
+// checkDeletes delivers delete events for the ids which disappeared since the last check.
+func (w *watcher[T]) checkDeletes() error {
+	ids, err := w.fetchIDs()
+	if err != nil {
+		return err
+	}
+
+	for id := range w.ids {
+		if _, ok := ids[id]; ok {
+			continue
+		}
+		e := Event[T]{Action: ActionDelete}
+		// only the id of a deleted record is known
+		raw, _ := json.Marshal(map[string]string{"id": id})
+		e.Error = json.Unmarshal(raw, &e.Record)
+		w.stream.push(e)
+	}
+	w.ids = ids
+	return nil
+}
+
+func (w *watcher[T]) list(after WatchMark, sort string, size int) ([]json.RawMessage, error) {
+	var response ResponseList[json.RawMessage]
+	_, err := w.collection.Client.List(w.collection.Name, ParamsList{
+		Page:            1,
+		Size:            size,
+		Filters:         watchFilter(after, w.opts.Filter),
+		Sort:            sort,
+		hackResponseRef: &response,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[watch] can't list %s, err %w", w.collection.Name, err)
+	}
+	return response.Items, nil
+}
+
+func (w *watcher[T]) fetchIDs() (map[string]struct{}, error) {
+	response, err := CollectionSet[cacheRecord](w.collection.Client, w.collection.Name).FullList(ParamsList{
+		Filters: watchFilter(WatchMark{}, w.opts.Filter),
+		Fields:  "id",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[watch] can't list %s ids, err %w", w.collection.Name, err)
+	}
+
+	ids := make(map[string]struct{}, len(response.Items))
+	for _, r := range response.Items {
+		ids[r.ID] = struct{}{}
+	}
+	return ids, nil
+}
+
+// watchFilter selects the records after the mark in the "updated,id" order.
+func watchFilter(after WatchMark, filter string) string {
+	var conditions []string
+	if after.Updated != "" {
+		updated, id := escapeFilterValue(after.Updated), escapeFilterValue(after.ID)
+		conditions = append(conditions, "(updated > '"+updated+"' || (updated = '"+updated+"' && id > '"+id+"'))")
+	}
+	if filter != "" {
+		conditions = append(conditions, "("+filter+")")
+	}
+	return strings.Join(conditions, " && ")
+}
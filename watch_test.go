@@ -0,0 +1,214 @@
+package pocketbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zcharym/pocketbase-client/migrations"
+)
+
+// waitEvent returns the first event of the record with the action, the polls may deliver
+// other events of the watched records before it.
+func waitEvent(t *testing.T, events <-chan Event[map[string]any], action string, id string) Event[map[string]any] {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			require.True(t, ok, "stream closed")
+			require.NoError(t, e.Error)
+			if e.Action == action && e.Record["id"] == id {
+				return e
+			}
+			t.Logf("skipped %s event of %v", e.Action, e.Record["id"])
+		case <-timeout:
+			t.Fatalf("no %s event of %s", action, id)
+		}
+	}
+}
+
+func TestCollection_Watch(t *testing.T) {
+	client := NewClient(defaultURL)
+	collection := CollectionSet[map[string]any](client, migrations.PostsPublic)
+	prefix := "watch_" + time.Now().Format("150405.000000")
+	// a stopped watch may still be saving its last poll, so t.TempDir can't remove the directory
+	dir, err := os.MkdirTemp("", "watch")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.Eventually(t, func() bool { return os.RemoveAll(dir) == nil }, time.Second, 10*time.Millisecond)
+	})
+	checkpoint := FileCheckpoint(filepath.Join(dir, "checkpoint.json"))
+
+	// existing records aren't delivered without a checkpoint
+	existing, err := collection.Create(map[string]any{"field": prefix + "_existing"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := collection.Watch(ctx, WatchOptions{
+		Interval:       50 * time.Millisecond,
+		Filter:         "field ~ '" + prefix + "'",
+		Checkpoint:     checkpoint,
+		DeleteInterval: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	events := stream.Events()
+
+	created, err := collection.Create(map[string]any{"field": prefix + "_created"})
+	require.NoError(t, err)
+	waitEvent(t, events, ActionCreate, created.ID)
+
+	require.NoError(t, collection.Update(existing.ID, map[string]any{"field": prefix + "_updated"}))
+	e := waitEvent(t, events, ActionUpdate, existing.ID)
+	assert.Equal(t, prefix+"_updated", e.Record["field"])
+
+	require.NoError(t, collection.Delete(created.ID))
+	e = waitEvent(t, events, ActionDelete, created.ID)
+	assert.Equal(t, map[string]any{"id": created.ID}, e.Record)
+
+	mark, err := checkpoint.Load()
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, mark.ID)
+
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+
+	t.Run("resume from the checkpoint", func(t *testing.T) {
+		missed, err := collection.Create(map[string]any{"field": prefix + "_missed"})
+		require.NoError(t, err)
+
+		stream, err := collection.Watch(context.Background(), WatchOptions{
+			Interval:   50 * time.Millisecond,
+			Filter:     "field ~ '" + prefix + "'",
+			Checkpoint: checkpoint,
+		})
+		require.NoError(t, err)
+		defer stream.Unsubscribe()
+
+		waitEvent(t, stream.Events(), ActionCreate, missed.ID)
+	})
+
+	t.Run("pipe saves the position of delivered events", func(t *testing.T) {
+		position := FileCheckpoint(filepath.Join(dir, "position.json"))
+		stream, err := collection.Watch(context.Background(), WatchOptions{
+			Interval:         50 * time.Millisecond,
+			Filter:           "field ~ '" + prefix + "'",
+			Checkpoint:       position,
+			ManualCheckpoint: true,
+		})
+		require.NoError(t, err)
+		defer stream.Unsubscribe()
+
+		// the starting position is saved before anything is delivered
+		start, err := position.Load()
+		require.NoError(t, err)
+		assert.NotZero(t, start)
+
+		first, err := collection.Create(map[string]any{"field": prefix + "_first"})
+		require.NoError(t, err)
+		second, err := collection.Create(map[string]any{"field": prefix + "_second"})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		delivered := make(chan string, 2)
+		pipeErr := make(chan error, 1)
+		go func() {
+			pipeErr <- Pipe(ctx, stream, PipeOptions{
+				Sinks: []Sink{SinkFunc(func(ctx context.Context, ev SinkEvent) error {
+					var r WatchMark
+					_ = json.Unmarshal(ev.Record, &r)
+					if r.ID == second.ID {
+						return errors.New("sink unavailable")
+					}
+					delivered <- r.ID
+					return nil
+				})},
+				Position: position,
+			})
+		}()
+
+		assert.Equal(t, first.ID, <-delivered)
+		assert.Error(t, <-pipeErr)
+		mark, err := position.Load()
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, mark.ID)
+
+		// a restarted watch delivers the failed event again
+		stream, err = collection.Watch(context.Background(), WatchOptions{
+			Interval:         50 * time.Millisecond,
+			Filter:           "field ~ '" + prefix + "'",
+			Checkpoint:       position,
+			ManualCheckpoint: true,
+		})
+		require.NoError(t, err)
+		defer stream.Unsubscribe()
+		waitEvent(t, stream.Events(), ActionCreate, second.ID)
+	})
+}
+
+func TestWatchMark_Precedes(t *testing.T) {
+	mark := WatchMark{Updated: "2024-05-01 10:00:00.123Z", ID: "m"}
+	at := func(value string) DateTime {
+		d, err := parseDateTime(value)
+		require.NoError(t, err)
+		return d
+	}
+	tests := []struct {
+		name    string
+		mark    WatchMark
+		created DateTime
+		id      string
+		want    bool
+	}{
+		{name: "Created after", mark: mark, created: at("2024-05-01 10:00:00.124Z"), id: "a", want: true},
+		{name: "Created before", mark: mark, created: at("2024-05-01 10:00:00.122Z"), id: "z"},
+		{name: "Same millisecond, greater id", mark: mark, created: at("2024-05-01 10:00:00.123Z"), id: "n", want: true},
+		{name: "Same millisecond, smaller id", mark: mark, created: at("2024-05-01 10:00:00.123Z"), id: "a"},
+		{name: "Same record", mark: mark, created: at("2024-05-01 10:00:00.123Z"), id: "m"},
+		{name: "Other format", mark: mark, created: at("2024-05-01T10:00:00.5Z"), id: "a", want: true},
+		{name: "Empty mark", created: at("2024-05-01 10:00:00.000Z"), id: "a", want: true},
+		{name: "No created date", mark: mark, id: "z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.mark.precedes(tt.created, tt.id))
+		})
+	}
+}
+
+func TestWatchFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		after  WatchMark
+		filter string
+		want   string
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:   "Filter only",
+			filter: "a = 1 || b = 2",
+			want:   "(a = 1 || b = 2)",
+		},
+		{
+			name:   "Mark and filter",
+			after:  WatchMark{Updated: "2024-05-01 10:00:00.000Z", ID: "it's"},
+			filter: "a = 1",
+			want:   `(updated > '2024-05-01 10:00:00.000Z' || (updated = '2024-05-01 10:00:00.000Z' && id > 'it\'s')) && (a = 1)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, watchFilter(tt.after, tt.filter))
+		})
+	}
+}